@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lucasepe/locker/internal/agent"
+)
+
+const (
+	// agentSockDirPrefix is the prefix of the temporary directory
+	// holding the agent socket.
+	agentSockDirPrefix = "locker-"
+	// agentStartTimeout is how long to wait for a background agent to listen.
+	agentStartTimeout = 3 * time.Second
+)
+
+func newCmdAgent() *cmdAgent {
+	return &cmdAgent{}
+}
+
+type cmdAgent struct {
+	sock       string
+	idle       time.Duration
+	lifetime   time.Duration
+	foreground bool
+	stdin      bool
+	secret     string
+}
+
+func (*cmdAgent) Name() string { return "agent" }
+func (*cmdAgent) Synopsis() string {
+	return "Start an agent that keeps the master secret in memory."
+}
+
+func (*cmdAgent) Usage() string {
+	return strings.ReplaceAll(strings.ReplaceAll(`{NAME} agent [flags]
+  
+   Start the agent in background and set its env var in the current shell:
+     eval $({NAME} agent)
+
+   Start the agent in foreground, locked after 5 minutes of inactivity:
+     {NAME} agent -d -idle 5m -a ~/.{NAME}.sock
+
+   The commands use the agent listening on the socket in the env var {ENV}
+   and don't need the master secret; the agent exits on '{NAME} lock',
+   after the idle timeout or after its lifetime.`, "{NAME}", appLowerName), "{ENV}", agent.EnvSock)
+}
+
+func (c *cmdAgent) SetFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.sock, "a", "", "Socket path (defaults to a new temporary directory).")
+	fs.DurationVar(&c.idle, "idle", lockTimeout(15*time.Minute), "Lock after this idle time (0 to disable).")
+	fs.DurationVar(&c.lifetime, "lifetime", 8*time.Hour, "Lock after this time, even if in use (0 to disable).")
+	fs.BoolVar(&c.foreground, "d", false, "Do not run in background.")
+	fs.BoolVar(&c.stdin, "stdin", false, "Read the master secret from stdin.")
+}
+
+func (c *cmdAgent) Execute(fs *flag.FlagSet) error {
+	if err := c.complete(fs); err != nil {
+		return err
+	}
+
+	if !c.foreground {
+		return c.start(fs)
+	}
+
+	l, err := net.Listen("unix", c.sock)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(c.sock, 0600); err != nil {
+		l.Close()
+		return err
+	}
+	defer removeSockDir(c.sock)
+
+	a := agent.New(agent.Options{
+		Secret:   c.secret,
+		Idle:     c.idle,
+		Lifetime: c.lifetime,
+	})
+	c.secret = ""
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+	go func() {
+		select {
+		case <-sigs:
+			a.Close()
+		case <-a.Done():
+		}
+	}()
+
+	printAgentEnv(fs.Output(), c.sock, os.Getpid())
+
+	return a.Serve(l)
+}
+
+// start runs the agent in background, passing the master secret
+// through a pipe, and waits for it to listen.
+func (c *cmdAgent) start(fs *flag.FlagSet) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	_, err = io.WriteString(w, c.secret)
+	w.Close()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(exe, c.Name(), "-d", "-stdin", "-a", c.sock,
+		"-idle", c.idle.String(), "-lifetime", c.lifetime.String())
+	cmd.Env = childEnviron()
+	cmd.Stdin = r
+	detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	pid := cmd.Process.Pid
+	cmd.Process.Release()
+
+	for start := time.Now(); time.Since(start) < agentStartTimeout; time.Sleep(20 * time.Millisecond) {
+		if conn, err := net.Dial("unix", c.sock); err == nil {
+			conn.Close()
+			printAgentEnv(fs.Output(), c.sock, pid)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("agent not listening on %s after %s", c.sock, agentStartTimeout)
+}
+
+func (c *cmdAgent) complete(fs *flag.FlagSet) error {
+	var err error
+	if c.stdin {
+		var dat []byte
+		dat, err = io.ReadAll(io.LimitReader(os.Stdin, maxFileSize))
+		c.secret = strings.TrimRight(string(dat), "\r\n")
+	} else {
+		c.secret, err = getMasterSecret(nil)
+	}
+	if err != nil {
+		return err
+	}
+	if len(c.secret) == 0 {
+		return ErrUnsetMasterSecret
+	}
+
+	if len(c.sock) == 0 {
+		dir, err := os.MkdirTemp("", agentSockDirPrefix)
+		if err != nil {
+			return err
+		}
+		c.sock = filepath.Join(dir, "agent.sock")
+	}
+
+	c.sock, err = filepath.Abs(c.sock)
+	return err
+}
+
+// printAgentEnv prints the shell commands setting the agent env var.
+func printAgentEnv(w io.Writer, sock string, pid int) {
+	fmt.Fprintf(w, "%s=%s; export %s;\n", agent.EnvSock, sock, agent.EnvSock)
+	fmt.Fprintf(w, "echo Agent pid %d;\n", pid)
+}
+
+// removeSockDir removes the temporary directory created for the socket.
+func removeSockDir(sock string) {
+	dir := filepath.Dir(sock)
+	if filepath.Dir(dir) == filepath.Clean(os.TempDir()) &&
+		strings.HasPrefix(filepath.Base(dir), agentSockDirPrefix) {
+		os.Remove(dir)
+	}
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lucasepe/locker/internal/agent"
+)
+
+func TestCmdAgent(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	dir, err := os.MkdirTemp("", "locker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sock := filepath.Join(dir, "agent.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- agent.New(agent.Options{Secret: testSecret}).Serve(l)
+	}()
+
+	// the commands get the master secret from the agent
+	t.Setenv(EnvSecret, "")
+	t.Setenv(agent.EnvSock, sock)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := runCmdGet(out, "password"); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "magick" {
+		t.Fatalf("expected: magick, got: %s", got)
+	}
+
+	out.Reset()
+	if err := runCmdAudit(out, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// the store is not opened through the agent
+	out.Reset()
+	if err := runCmdInfoArgs(out, "-s", testStore, "-o", fmtJSON); err != nil {
+		t.Fatal(err)
+	}
+	if want := `"master_secret": "unverified"`; !strings.Contains(out.String(), want) {
+		t.Fatalf("expected %q in: %s", want, out.String())
+	}
+
+	out.Reset()
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(out)
+	if err := newCmdLock().Execute(fs); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "agent locked\n" {
+		t.Fatalf("unexpected output: %s", got)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("agent still serving after lock")
+	}
+
+	if err := runCmdGet(out, "password"); err == nil {
+		t.Fatal("expected error using a locked agent")
+	}
+
+	// the values have been encrypted with the agent secret
+	os.Unsetenv(agent.EnvSock)
+	t.Setenv(EnvSecret, testSecret)
+
+	out.Reset()
+	if err := runCmdGet(out, "password"); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "magick" {
+		t.Fatalf("expected: magick, got: %s", got)
+	}
+}
+
+func TestCmdAgentStoreSecret(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	dir, err := os.MkdirTemp("", "locker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sock := filepath.Join(dir, "agent.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := agent.New(agent.Options{Secret: "agent secret"})
+	go a.Serve(l)
+	defer a.Close()
+
+	// the store has its own secret, not held by the agent
+	t.Setenv(EnvSecret, "")
+	t.Setenv("LOCKER_SECRET_TEST", testSecret)
+	t.Setenv(agent.EnvSock, sock)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := verifyMasterSecret(testArchivePath(), testSecret)
+	if err != nil || !ok {
+		t.Fatalf("expected the store secret to unlock the store (%v)", err)
+	}
+}
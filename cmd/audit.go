@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lucasepe/locker/cmd/flags"
+	"github.com/lucasepe/locker/internal/audit"
+	"github.com/lucasepe/locker/internal/kv"
+)
+
+func newCmdAudit() *cmdAudit {
+	return &cmdAudit{
+		namespace: flags.Namespace{},
+		storeRef:  newStoreFlag(),
+		output:    flags.Enum{Choices: []string{fmtTxt, fmtJSON, fmtYAML}},
+	}
+}
+
+type cmdAudit struct {
+	namespace flags.Namespace
+	storeRef  flags.Store
+	output    flags.Enum
+	verify    bool
+}
+
+func (*cmdAudit) Name() string { return "audit" }
+func (*cmdAudit) Synopsis() string {
+	return "Query and verify the log of secret accesses."
+}
+
+func (*cmdAudit) Usage() string {
+	return strings.ReplaceAll(`{NAME} audit [flags]
+  
+   Show who accessed the secrets in the 'google' namespace:
+     {NAME} audit -n google
+
+   Check that the audit log of the 'work' store has not been tampered with:
+     {NAME} audit -s work -verify`, "{NAME}", appLowerName)
+}
+
+func (c *cmdAudit) SetFlags(fs *flag.FlagSet) {
+	fs.Var(&c.namespace, "n", "Namespace.")
+	fs.Var(&c.storeRef, "s", "Store name, alias or path.")
+	fs.Var(&c.output, "o", fmt.Sprintf("Output format, one of: %s", strings.Join(c.output.Choices, ",")))
+	fs.BoolVar(&c.verify, "verify", false, "Verify the audit log integrity.")
+}
+
+func (c *cmdAudit) Execute(fs *flag.FlagSet) error {
+	if err := c.complete(fs); err != nil {
+		return err
+	}
+
+	sto, err := c.storeRef.Connect()
+	if err != nil {
+		return err
+	}
+	defer sto.Close()
+
+	j, ok := sto.(kv.Journal)
+	if !ok {
+		return fmt.Errorf("store does not support audit logs")
+	}
+	log := audit.New(j)
+
+	if c.verify {
+		count, err := log.Verify()
+		if err != nil {
+			return err
+		}
+		if structured(c.output.Value) {
+			return encodeOutput(fs.Output(), c.output.Value, auditVerifyResult{Verified: true, Entries: count})
+		}
+		fmt.Fprintf(fs.Output(), "audit log verified (entries: %d)\n", count)
+		return nil
+	}
+
+	entries := []audit.Entry{}
+	tw := tabwriter.NewWriter(fs.Output(), 0, 4, 2, ' ', 0)
+	err = log.Entries(func(el audit.Entry) error {
+		if len(c.namespace.Bytes()) > 0 && el.Namespace != c.namespace.String() {
+			return nil
+		}
+
+		if structured(c.output.Value) {
+			entries = append(entries, el)
+			return nil
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", el.Time.Local().Format(time.RFC3339),
+			el.User, el.Operation, el.Namespace, strings.Join(el.Keys, ","))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if structured(c.output.Value) {
+		return encodeOutput(fs.Output(), c.output.Value, entries)
+	}
+
+	return tw.Flush()
+}
+
+func (c *cmdAudit) complete(fs *flag.FlagSet) error {
+	resolveOutput(&c.output, fmtTxt)
+
+	pwd, err := getMasterSecret(&c.storeRef)
+	if err != nil {
+		return err
+	}
+	c.storeRef.MasterSecret = pwd
+
+	return nil
+}
+
+// auditVerifyResult is the structured output of audit -verify.
+type auditVerifyResult struct {
+	Verified bool `json:"verified" yaml:"verified"`
+	Entries  int  `json:"entries" yaml:"entries"`
+}
+
+// recordAccess appends an entry to the store audit log,
+// if the store supports it.
+func recordAccess(sto kv.Store, op, namespace string, keys ...string) error {
+	j, ok := sto.(kv.Journal)
+	if !ok {
+		return nil
+	}
+
+	if err := audit.New(j).Record(op, namespace, keys...); err != nil {
+		return fmt.Errorf("unable to write the audit log: %w", err)
+	}
+
+	return nil
+}
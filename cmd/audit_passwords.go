@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lucasepe/locker/cmd/flags"
+	"github.com/lucasepe/locker/internal/kv"
+	"github.com/lucasepe/locker/internal/strength"
+)
+
+// passwordKeyParts are the key parts identifying passwords
+// (i.e. 'password', 'db_password', 'api_key').
+var passwordKeyParts = []string{
+	"password", "passwd", "pass", "pwd", "passphrase", "pin", "secret", "token", "key", "apikey",
+}
+
+func newCmdAuditPasswords() *cmdAuditPasswords {
+	return &cmdAuditPasswords{
+		storeRef: newStoreFlag(),
+		output:   flags.Enum{Choices: []string{fmtTxt, fmtJSON, fmtYAML}},
+	}
+}
+
+type cmdAuditPasswords struct {
+	storeRef   flags.Store
+	output     flags.Enum
+	minEntropy float64
+	maxAge     int
+	all        bool
+}
+
+func (*cmdAuditPasswords) Name() string { return "audit-passwords" }
+func (*cmdAuditPasswords) Synopsis() string {
+	return "Report weak, reused and old passwords."
+}
+
+func (*cmdAuditPasswords) Usage() string {
+	return strings.ReplaceAll(`{NAME} audit-passwords [flags]
+  
+   Report passwords reused, weak or not changed in the last 90 days:
+     {NAME} audit-passwords -max-age 90
+
+   Check every secret (not only passwords) and print a JSON report:
+     {NAME} audit-passwords -all -o json`, "{NAME}", appLowerName)
+}
+
+func (c *cmdAuditPasswords) SetFlags(fs *flag.FlagSet) {
+	fs.Var(&c.storeRef, "s", "Store name, alias or path.")
+	fs.Var(&c.output, "o", fmt.Sprintf("Output format, one of: %s", strings.Join(c.output.Choices, ",")))
+	fs.Float64Var(&c.minEntropy, "min-entropy", 60, "Minimum estimated entropy (bits).")
+	fs.IntVar(&c.maxAge, "max-age", 365, "Maximum days since the last change (0 to disable).")
+	fs.BoolVar(&c.all, "all", false, "Check all secrets, not only the ones whose key looks like a password.")
+}
+
+func (c *cmdAuditPasswords) Execute(fs *flag.FlagSet) error {
+	if err := c.complete(fs); err != nil {
+		return err
+	}
+
+	sto, err := c.storeRef.Connect()
+	if err != nil {
+		return err
+	}
+	defer sto.Close()
+
+	syn, ok := sto.(kv.Syncer)
+	if !ok {
+		return fmt.Errorf("store does not track secrets modification times")
+	}
+
+	all, err := syn.Entries()
+	if err != nil {
+		return err
+	}
+
+	var entries []kv.Entry
+	checked := map[string][]string{}
+	for _, el := range all {
+		if el.Deleted || (!c.all && !isPasswordKey(el.Key)) {
+			continue
+		}
+		entries = append(entries, el)
+		checked[el.Namespace] = append(checked[el.Namespace], el.Key)
+	}
+
+	for ns, keys := range checked {
+		if err := recordAccess(sto, "audit-passwords", ns, keys...); err != nil {
+			return err
+		}
+	}
+
+	res := c.check(entries, time.Now())
+	if structured(c.output.Value) {
+		return encodeOutput(fs.Output(), c.output.Value, res)
+	}
+
+	if len(res) == 0 {
+		fmt.Fprintf(fs.Output(), "no issues found (secrets checked: %d)\n", len(entries))
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(fs.Output(), 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAMESPACE\tKEY\tISSUES")
+	for _, el := range res {
+		reasons := make([]string, len(el.Issues))
+		for i, is := range el.Issues {
+			reasons[i] = is.Detail
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", el.Namespace, el.Key, strings.Join(reasons, "; "))
+	}
+
+	return tw.Flush()
+}
+
+// check returns the secrets with issues, sorted by namespace and key.
+func (c *cmdAuditPasswords) check(entries []kv.Entry, now time.Time) []passwordReport {
+	byValue := map[string][]string{}
+	for _, el := range entries {
+		byValue[el.Value] = append(byValue[el.Value], el.Namespace+"/"+el.Key)
+	}
+
+	var res []passwordReport
+	for _, el := range entries {
+		ref := el.Namespace + "/" + el.Key
+		rep := passwordReport{Namespace: el.Namespace, Key: el.Key}
+
+		if refs := byValue[el.Value]; len(refs) > 1 {
+			others := make([]string, 0, len(refs)-1)
+			for _, r := range refs {
+				if r != ref {
+					others = append(others, r)
+				}
+			}
+			sort.Strings(others)
+			rep.add("reused", fmt.Sprintf("reused by %s", strings.Join(others, ", ")))
+		}
+
+		if strength.Common(el.Value) {
+			rep.add("common", "one of the most common passwords")
+		} else if strength.Dictionary(el.Value) {
+			rep.add("dictionary", "based on dictionary words")
+		}
+
+		if bits := strength.Entropy(el.Value); bits < c.minEntropy {
+			rep.add("weak", fmt.Sprintf("low entropy (%d bits)", int(bits)))
+		}
+
+		if c.maxAge > 0 && !el.Modified.IsZero() {
+			if days := int(now.Sub(el.Modified).Hours() / 24); days > c.maxAge {
+				rep.add("old", fmt.Sprintf("not changed in %d days", days))
+			}
+		}
+
+		if len(rep.Issues) > 0 {
+			res = append(res, rep)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Namespace != res[j].Namespace {
+			return res[i].Namespace < res[j].Namespace
+		}
+		return res[i].Key < res[j].Key
+	})
+
+	return res
+}
+
+func (c *cmdAuditPasswords) complete(fs *flag.FlagSet) error {
+	resolveOutput(&c.output, fmtTxt)
+
+	pwd, err := getMasterSecret(&c.storeRef)
+	if err != nil {
+		return err
+	}
+	c.storeRef.MasterSecret = pwd
+
+	return nil
+}
+
+// passwordReport lists the issues of a secret; it never holds the value.
+type passwordReport struct {
+	Namespace string          `json:"namespace"`
+	Key       string          `json:"key"`
+	Issues    []passwordIssue `json:"issues"`
+}
+
+type passwordIssue struct {
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
+}
+
+func (r *passwordReport) add(typ, detail string) {
+	r.Issues = append(r.Issues, passwordIssue{Type: typ, Detail: detail})
+}
+
+func isPasswordKey(key string) bool {
+	for _, part := range strings.Split(key, "_") {
+		if contains(passwordKeyParts, part) {
+			return true
+		}
+	}
+	return false
+}
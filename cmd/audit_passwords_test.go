@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCmdAuditPasswords(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "user name", "pinco.pallo@gmail.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCmdPut(out, "password", "Sunshine2023"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCmdPut(out, "db password", "Sunshine2023"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCmdPut(out, "api key", "x7#Kq9!mZ2@wLp4$"); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := runCmdAuditPasswords(out, "-o", "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(out.String(), "Sunshine2023") {
+		t.Fatalf("report must not contain secret values: %s", out.String())
+	}
+
+	var got []passwordReport
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []passwordReport{
+		{
+			Namespace: testNamespace, Key: "db_password",
+			Issues: []passwordIssue{
+				{Type: "reused", Detail: "reused by stuffs/password"},
+				{Type: "dictionary", Detail: "based on dictionary words"},
+				{Type: "weak", Detail: "low entropy (59 bits)"},
+			},
+		},
+		{
+			Namespace: testNamespace, Key: "password",
+			Issues: []passwordIssue{
+				{Type: "reused", Detail: "reused by stuffs/db_password"},
+				{Type: "dictionary", Detail: "based on dictionary words"},
+				{Type: "weak", Detail: "low entropy (59 bits)"},
+			},
+		},
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Fatal(cmp.Diff(want, got))
+	}
+}
+
+func runCmdAuditPasswords(output io.Writer, extra ...string) error {
+	op := newCmdAuditPasswords()
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	op.SetFlags(fs)
+
+	args := append([]string{"-s", testStore}, extra...)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return op.Execute(fs)
+}
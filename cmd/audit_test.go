@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCmdAudit(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCmdGet(out, "password"); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := runCmdAudit(out, false); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 entries, got: %v", lines)
+	}
+
+	for i, op := range []string{"put", "get"} {
+		got := strings.Fields(lines[i])
+		want := []string{op, testNamespace, "password"}
+		if strings.Join(got[2:], " ") != strings.Join(want, " ") {
+			t.Fatalf("expected: %v, got: %v", want, got[2:])
+		}
+	}
+
+	out.Reset()
+	if err := runCmdAudit(out, true); err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.TrimSpace(out.String())
+	want := "audit log verified (entries: 2)"
+	if got != want {
+		t.Fatalf("expected: %s, got: %s", want, got)
+	}
+}
+
+func runCmdAudit(output io.Writer, verify bool) error {
+	op := newCmdAudit()
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	op.SetFlags(fs)
+
+	args := []string{
+		"-s", testStore,
+	}
+	if verify {
+		args = append(args, "-verify")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return op.Execute(fs)
+}
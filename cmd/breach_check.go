@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lucasepe/locker/cmd/flags"
+	"github.com/lucasepe/locker/internal/pwned"
+)
+
+func newCmdBreachCheck() *cmdBreachCheck {
+	return &cmdBreachCheck{
+		db:       flags.FileFlag{},
+		storeRef: newStoreFlag(),
+		output:   flags.Enum{Choices: []string{fmtTxt, fmtJSON, fmtYAML}},
+	}
+}
+
+type cmdBreachCheck struct {
+	db       flags.FileFlag
+	storeRef flags.Store
+	output   flags.Enum
+	all      bool
+}
+
+func (*cmdBreachCheck) Name() string { return "breach-check" }
+func (*cmdBreachCheck) Synopsis() string {
+	return "Look up passwords in a local copy of the breached passwords hashes."
+}
+
+func (*cmdBreachCheck) Usage() string {
+	return strings.ReplaceAll(`{NAME} breach-check [flags]
+  
+   Look up the passwords in the Have I Been Pwned SHA-1 hashes file (ordered by hash):
+     {NAME} breach-check -db pwned-passwords-sha1-ordered-by-hash.txt
+
+   Look up every secret in a directory of downloaded range files (i.e. 21BD1.txt):
+     {NAME} breach-check -all -db /mnt/share/pwned`, "{NAME}", appLowerName)
+}
+
+func (c *cmdBreachCheck) SetFlags(fs *flag.FlagSet) {
+	fs.Var(&c.storeRef, "s", "Store name, alias or path.")
+	fs.Var(&c.db, "db", "Sorted SHA-1 hashes file (or directory of range files).")
+	fs.Var(&c.output, "o", fmt.Sprintf("Output format, one of: %s", strings.Join(c.output.Choices, ",")))
+	fs.BoolVar(&c.all, "all", false, "Check all secrets, not only the ones whose key looks like a password.")
+}
+
+func (c *cmdBreachCheck) Execute(fs *flag.FlagSet) error {
+	if err := c.complete(fs); err != nil {
+		return err
+	}
+
+	db, err := pwned.Open(c.db.String())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	sto, err := c.storeRef.Connect()
+	if err != nil {
+		return err
+	}
+	defer sto.Close()
+
+	namespaces, err := sto.Namespaces()
+	if err != nil {
+		return err
+	}
+
+	res := []breachReport{}
+	checked := 0
+	for _, ns := range namespaces {
+		all, err := sto.GetAll(ns)
+		if err != nil {
+			return err
+		}
+
+		keys := []string{}
+		for k, v := range all {
+			if !c.all && !isPasswordKey(k) {
+				continue
+			}
+			keys = append(keys, k)
+
+			count, err := db.Lookup(v)
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				res = append(res, breachReport{Namespace: ns, Key: k, Count: count})
+			}
+		}
+
+		if len(keys) == 0 {
+			continue
+		}
+		checked += len(keys)
+
+		if err := recordAccess(sto, "breach-check", ns, keys...); err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Namespace != res[j].Namespace {
+			return res[i].Namespace < res[j].Namespace
+		}
+		return res[i].Key < res[j].Key
+	})
+
+	if structured(c.output.Value) {
+		return encodeOutput(fs.Output(), c.output.Value, res)
+	}
+
+	if len(res) == 0 {
+		fmt.Fprintf(fs.Output(), "no compromised secrets found (secrets checked: %d)\n", checked)
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(fs.Output(), 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAMESPACE\tKEY\tSEEN")
+	for _, el := range res {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", el.Namespace, el.Key, el.Count)
+	}
+
+	return tw.Flush()
+}
+
+func (c *cmdBreachCheck) complete(fs *flag.FlagSet) error {
+	resolveOutput(&c.output, fmtTxt)
+
+	if len(c.db.String()) == 0 {
+		return fmt.Errorf("breached passwords hashes file not specified")
+	}
+
+	pwd, err := getMasterSecret(&c.storeRef)
+	if err != nil {
+		return err
+	}
+	c.storeRef.MasterSecret = pwd
+
+	return nil
+}
+
+// breachReport is a compromised secret; it never holds the value.
+type breachReport struct {
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+	// Count is how many times the value has been seen in breaches.
+	Count int `json:"count"`
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCmdBreachCheck(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "password", "Sunshine2023"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCmdPut(out, "api key", "x7#Kq9!mZ2@wLp4$"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCmdPut(out, "user name", "qwerty"); err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for i, pwd := range []string{"Sunshine2023", "qwerty", "123456", "password"} {
+		sum := sha1.Sum([]byte(pwd))
+		lines = append(lines, fmt.Sprintf("%s:%d", strings.ToUpper(hex.EncodeToString(sum[:])), i+5))
+	}
+	sort.Strings(lines)
+
+	db := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(db, []byte(strings.Join(lines, "\r\n")), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := runCmdBreachCheck(out, "-db", db, "-o", "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []breachReport
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []breachReport{{Namespace: testNamespace, Key: "password", Count: 5}}
+	if !cmp.Equal(want, got) {
+		t.Fatal(cmp.Diff(want, got))
+	}
+
+	out.Reset()
+	if err := runCmdBreachCheck(out, "-db", db, "-all", "-o", "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	got = nil
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want = append(want, breachReport{Namespace: testNamespace, Key: "user_name", Count: 6})
+	if !cmp.Equal(want, got) {
+		t.Fatal(cmp.Diff(want, got))
+	}
+}
+
+func runCmdBreachCheck(output io.Writer, extra ...string) error {
+	op := newCmdBreachCheck()
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	op.SetFlags(fs)
+
+	args := append([]string{"-s", testStore}, extra...)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return op.Execute(fs)
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/lucasepe/locker/internal/clipboard"
+)
+
+const (
+	// envClipClear holds the timeout of the background process
+	// clearing the clipboard (see clearClipboard).
+	envClipClear       = "LOCKER_CLIPBOARD_CLEAR"
+	defaultClipTimeout = 45 * time.Second
+)
+
+// copyToClipboard copies the value to the clipboard and, when timeout
+// is not zero, starts a background process clearing it after the timeout
+// (only if it still holds the value); clearIn is zero if the
+// clipboard will not be cleared.
+func copyToClipboard(val string, timeout time.Duration) (clearIn time.Duration, err error) {
+	cb, err := clipboard.Detect()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := cb.Write(val); err != nil {
+		return 0, err
+	}
+
+	if timeout <= 0 || !cb.Readable() {
+		return 0, nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+
+	// the process gets only the hash of the value
+	r, w, err := os.Pipe()
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	_, err = io.WriteString(w, clipHash(val))
+	w.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	cmd := exec.Command(exe)
+	cmd.Env = append(childEnviron(envClipClear),
+		fmt.Sprintf("%s=%s", envClipClear, timeout))
+	cmd.Stdin = r
+	detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+
+	return timeout, cmd.Process.Release()
+}
+
+// clearClipboard runs in the background process started by copyToClipboard:
+// after the timeout it clears the clipboard if it still holds the value
+// whose hash is read from stdin.
+func clearClipboard(timeout string) error {
+	after, err := time.ParseDuration(timeout)
+	if err != nil {
+		return err
+	}
+
+	dat, err := io.ReadAll(io.LimitReader(os.Stdin, 2*sha256.Size))
+	if err != nil {
+		return err
+	}
+	sum := strings.TrimSpace(string(dat))
+
+	time.Sleep(after)
+
+	cb, err := clipboard.Detect()
+	if err != nil {
+		return err
+	}
+
+	_, err = cb.ClearIf(func(content string) bool {
+		return clipHash(content) == sum
+	})
+	return err
+}
+
+func clipHash(val string) string {
+	sum := sha256.Sum256([]byte(val))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lucasepe/locker/cmd/flags"
+	"github.com/lucasepe/locker/internal/kv/bbolt"
+	"github.com/lucasepe/strcase"
+)
+
+const (
+	// completeCmdName is the hidden command printing the completions
+	// of the command line passed as arguments.
+	completeCmdName = "__complete"
+	// completeTimeout is how long to wait for a store locked by another process.
+	completeTimeout = 200 * time.Millisecond
+)
+
+// completionScripts are the completion scripts by shell; each one
+// asks the candidates to '{NAME} __complete <words>', falling back
+// to files when there are none.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for {NAME}
+_{NAME}() {
+    local IFS=$'\n'
+    COMPREPLY=($({NAME} __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _{NAME} {NAME}
+`,
+	"zsh": `#compdef {NAME}
+# zsh completion for {NAME}
+_{NAME}() {
+    local out
+    out=$({NAME} __complete "${(@)words[2,CURRENT]}" 2>/dev/null)
+    if [[ -z "$out" ]]; then
+        _files
+        return
+    fi
+    local -a candidates
+    candidates=("${(@f)out}")
+    compadd -a candidates
+}
+compdef _{NAME} {NAME}
+`,
+	"fish": `# fish completion for {NAME}
+function __{NAME}_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    set -l res ({NAME} __complete $tokens[2..-1] 2>/dev/null)
+    if test (count $res) -eq 0
+        __fish_complete_path (commandline -ct)
+    else
+        printf '%s\n' $res
+    end
+end
+complete -c {NAME} -f -a '(__{NAME}_complete)'
+`,
+}
+
+func newCmdCompletion() *cmdCompletion {
+	return &cmdCompletion{}
+}
+
+type cmdCompletion struct{}
+
+func (*cmdCompletion) Name() string { return "completion" }
+func (*cmdCompletion) Synopsis() string {
+	return "Generate the shell completion script (bash, zsh or fish)."
+}
+
+func (*cmdCompletion) Usage() string {
+	return strings.ReplaceAll(`{NAME} completion bash|zsh|fish
+
+   Enable the completion in the current bash session:
+     source <({NAME} completion bash)
+
+   Install the completion for zsh (the directory must be in your $fpath):
+     {NAME} completion zsh > ~/.zsh/completions/_{NAME}
+
+   Install the completion for fish:
+     {NAME} completion fish > ~/.config/fish/completions/{NAME}.fish
+
+   Besides commands and flags, the values of -s, -n and -k are completed
+   reading the store, without the master secret.`, "{NAME}", appLowerName)
+}
+
+func (c *cmdCompletion) SetFlags(fs *flag.FlagSet) {}
+
+func (c *cmdCompletion) Execute(fs *flag.FlagSet) error {
+	if fs.NArg() != 1 {
+		return fmt.Errorf("specify the shell, one of: bash,fish,zsh")
+	}
+
+	script, ok := completionScripts[fs.Arg(0)]
+	if !ok {
+		return fmt.Errorf("unsupported shell: %s (use one of: bash,fish,zsh)", fs.Arg(0))
+	}
+
+	fmt.Fprint(fs.Output(), strings.ReplaceAll(script, "{NAME}", appLowerName))
+
+	return nil
+}
+
+// complete prints, one per line, the candidates for the last of the words
+// typed after the program name (the one being completed, possibly empty).
+func complete(w io.Writer, words []string) error {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur := words[len(words)-1]
+
+	for _, el := range completeWords(words) {
+		if strings.HasPrefix(el, cur) {
+			fmt.Fprintln(w, el)
+		}
+	}
+
+	return nil
+}
+
+// completeWords returns the candidates for the last of the words.
+func completeWords(words []string) []string {
+	// the flags before the command name (i.e. 'locker -o json get')
+	gfs := flag.NewFlagSet(appLowerName, flag.ContinueOnError)
+	setGlobalFlags(gfs)
+	for len(words) > 1 && strings.HasPrefix(words[0], "-") {
+		fl := lookupFlag(gfs, words[0])
+		if fl == nil || isBoolFlag(fl) {
+			words = words[1:]
+			continue
+		}
+		if len(words) == 2 {
+			return completeFlagValue(gfs, fl, nil)
+		}
+		words = words[2:]
+	}
+
+	var res []string
+	switch {
+	case len(words) == 1 && strings.HasPrefix(words[0], "-"):
+		gfs.VisitAll(func(fl *flag.Flag) {
+			res = append(res, "-"+fl.Name)
+		})
+	case len(words) == 1 || (len(words) == 2 && words[0] == "help"):
+		res = append(res, "help")
+		for _, el := range commands("", "") {
+			res = append(res, el.Name())
+		}
+		sort.Strings(res)
+	case len(words) == 2 && words[0] == "completion":
+		for shell := range completionScripts {
+			res = append(res, shell)
+		}
+		sort.Strings(res)
+	default:
+		res = completeArgs(words[0], words[1:len(words)-1], words[len(words)-1])
+	}
+
+	return res
+}
+
+// completeArgs returns the flags of the command or the values of the flag
+// preceding the current word.
+func completeArgs(name string, args []string, cur string) []string {
+	var cmd interface{ SetFlags(*flag.FlagSet) }
+	for _, el := range commands("", "") {
+		if el.Name() == name {
+			cmd = el
+		}
+	}
+	if cmd == nil {
+		return nil
+	}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	cmd.SetFlags(fs)
+
+	if len(args) > 0 {
+		if fl := lookupFlag(fs, args[len(args)-1]); fl != nil && !isBoolFlag(fl) {
+			return completeFlagValue(fs, fl, args)
+		}
+	}
+
+	if !strings.HasPrefix(cur, "-") {
+		return nil
+	}
+
+	var res []string
+	fs.VisitAll(func(fl *flag.Flag) {
+		res = append(res, "-"+fl.Name)
+	})
+	return res
+}
+
+// completeFlagValue returns the values for the flag: the stores for -s,
+// the namespaces for -n and the keys for -k (reading the store and the
+// namespace typed in the other flags) and the choices of the enum flags.
+func completeFlagValue(fs *flag.FlagSet, fl *flag.Flag, args []string) []string {
+	if enum, ok := fl.Value.(*flags.Enum); ok {
+		return enum.Choices
+	}
+
+	switch fl.Name {
+	case "s":
+		stores, _ := listStores()
+		res := make([]string, 0, len(stores))
+		for name := range stores {
+			res = append(res, name)
+		}
+		sort.Strings(res)
+		return res
+	case "n", "k":
+	default:
+		return nil
+	}
+
+	storeRef := newStoreFlag()
+	if val := flagValue(fs, args, "s"); len(val) > 0 {
+		storeRef.Set(val)
+	}
+	if !storeRef.Exists() {
+		return nil
+	}
+
+	sto, err := bbolt.NewStore(bbolt.Options{
+		Path:     storeRef.String(),
+		ReadOnly: true,
+		Timeout:  completeTimeout,
+	})
+	if err != nil {
+		return nil
+	}
+	defer sto.Close()
+
+	var res []string
+	if fl.Name == "n" {
+		res, _ = sto.Namespaces()
+	} else if ns := flagValue(fs, args, "n"); len(ns) > 0 {
+		res, _ = sto.Keys(strcase.Kebab(ns))
+	}
+
+	return res
+}
+
+// lookupFlag returns the flag named by the word (i.e. '-n' or '--n').
+func lookupFlag(fs *flag.FlagSet, word string) *flag.Flag {
+	if !strings.HasPrefix(word, "-") || strings.Contains(word, "=") {
+		return nil
+	}
+	return fs.Lookup(strings.TrimLeft(word, "-"))
+}
+
+// flagValue returns the last value typed for the flag.
+func flagValue(fs *flag.FlagSet, args []string, name string) (res string) {
+	for i, el := range args {
+		if val, ok := strings.CutPrefix(strings.TrimLeft(el, "-"), name+"="); ok && strings.HasPrefix(el, "-") {
+			res = val
+			continue
+		}
+		if fl := lookupFlag(fs, el); fl != nil && fl.Name == name && i+1 < len(args) {
+			res = args[i+1]
+		}
+	}
+	return res
+}
+
+func isBoolFlag(fl *flag.Flag) bool {
+	bf, ok := fl.Value.(interface{ IsBoolFlag() bool })
+	return ok && bf.IsBoolFlag()
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestComplete(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "user name", "pinco.pallo@gmail.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the master secret is not needed
+	t.Setenv(EnvSecret, "")
+
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"to"}, []string{"totp"}},
+		{[]string{"-"}, []string{"-o"}},
+		{[]string{"-o", ""}, []string{"txt", "json", "yaml"}},
+		{[]string{"-o", "json", "li"}, []string{"list"}},
+		{[]string{"-o=yaml", "list", "-s", "te"}, []string{"test"}},
+		{[]string{"help", "ex"}, []string{"exec", "export"}},
+		{[]string{"completion", ""}, []string{"bash", "fish", "zsh"}},
+		{[]string{"delete", "-"}, []string{"-dry-run", "-k", "-n", "-s", "-y", "-yes"}},
+		{[]string{"get", "-s", "te"}, []string{"test"}},
+		{[]string{"get", "-s", "test", "-n", ""}, []string{testNamespace}},
+		{[]string{"get", "-s", "test", "-n", "Stuffs", "-k", ""}, []string{"password", "user_name"}},
+		{[]string{"get", "-s=test", "-n=stuffs", "-k", "u"}, []string{"user_name"}},
+		{[]string{"get", "-s", "test", "-o", "d"}, []string{"dotenv", "docker"}},
+		{[]string{"unknown", "-"}, nil},
+	}
+
+	for _, tc := range tests {
+		out.Reset()
+		if err := complete(out, tc.words); err != nil {
+			t.Fatal(err)
+		}
+
+		got := strings.Fields(out.String())
+		if !cmp.Equal(tc.want, got, cmpopts.EquateEmpty()) {
+			t.Fatalf("%v: %s", tc.words, cmp.Diff(tc.want, got, cmpopts.EquateEmpty()))
+		}
+	}
+}
+
+func TestCmdCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		out := bytes.NewBufferString("")
+
+		fs := flag.NewFlagSet("", flag.ContinueOnError)
+		fs.SetOutput(out)
+		fs.Parse([]string{shell})
+
+		if err := newCmdCompletion().Execute(fs); err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(out.String(), "locker __complete") {
+			t.Fatalf("%s: unexpected script: %s", shell, out.String())
+		}
+	}
+}
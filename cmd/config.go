@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lucasepe/locker/cmd/flags"
+	"github.com/lucasepe/locker/internal/config"
+	"github.com/zalando/go-keyring"
+)
+
+// cfg holds the defaults read from the configuration file; the precedence
+// is: command line flags, env vars, configuration file, built-in defaults.
+var cfg = &config.Config{}
+
+// loadConfig reads the configuration file in AppDir.
+func loadConfig() (err error) {
+	cfg, err = config.Load(filepath.Join(AppDir(), config.FileName))
+	return err
+}
+
+// newStoreFlag returns the value of the -s flag, with the default
+// store (of the env var or of the configuration) and the store aliases.
+func newStoreFlag() flags.Store {
+	def := os.Getenv(EnvStore)
+	if len(def) == 0 {
+		def = cfg.Store
+	}
+
+	return flags.Store{
+		BaseDir: AppDir(),
+		Default: def,
+		Aliases: cfg.Aliases(),
+	}
+}
+
+// clipboardTimeout returns the default of the -clip-timeout flags.
+func clipboardTimeout() time.Duration {
+	if cfg.ClipboardTimeout != nil {
+		return *cfg.ClipboardTimeout
+	}
+	return defaultClipTimeout
+}
+
+// lockTimeout returns the default idle time after which the stores are locked.
+func lockTimeout(def time.Duration) time.Duration {
+	if cfg.LockTimeout != nil {
+		return *cfg.LockTimeout
+	}
+	return def
+}
+
+// configSecret returns the master secret of the store from the source
+// set in the configuration; ok is false when there is none.
+func configSecret(storeRef *flags.Store) (secret string, ok bool, err error) {
+	if storeRef == nil {
+		return "", false, nil
+	}
+
+	src := cfg.Stores[storeRef.Name()].Secret
+	if len(src) == 0 {
+		return "", false, nil
+	}
+
+	secret, err = readSecretSource(src)
+	if err != nil {
+		return "", true, fmt.Errorf("master secret of store %s: %w", storeRef.Name(), err)
+	}
+	if len(secret) == 0 {
+		return "", true, fmt.Errorf("master secret of store %s: %s is empty", storeRef.Name(), src)
+	}
+
+	return secret, true, nil
+}
+
+// readSecretSource reads a secret from env:NAME, keyring:USER,
+// file:PATH or cmd:COMMAND (the first line of its output).
+func readSecretSource(src string) (string, error) {
+	kind, arg, _ := strings.Cut(src, ":")
+	if len(arg) == 0 {
+		return "", fmt.Errorf("invalid secret source: %s", src)
+	}
+
+	switch kind {
+	case "env":
+		return os.Getenv(arg), nil
+	case "keyring":
+		return keyring.Get(EnvSecret, arg)
+	case "file":
+		if strings.HasPrefix(arg, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			arg = filepath.Join(home, arg[2:])
+		}
+		dat, err := os.ReadFile(arg)
+		if err != nil {
+			return "", err
+		}
+		return firstLine(dat), nil
+	case "cmd":
+		args, err := shellFields(arg)
+		if err != nil || len(args) == 0 {
+			return "", fmt.Errorf("invalid secret source: %s", src)
+		}
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin = os.Stdin
+		cmd.Stderr = os.Stderr
+		dat, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", arg, err)
+		}
+		return firstLine(dat), nil
+	}
+
+	return "", fmt.Errorf("unsupported secret source: %s (use env:, keyring:, file: or cmd:)", src)
+}
+
+func firstLine(dat []byte) string {
+	line, _, _ := bytes.Cut(dat, []byte("\n"))
+	return string(bytes.TrimSuffix(line, []byte("\r")))
+}
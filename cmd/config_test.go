@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/lucasepe/locker/internal/config"
+)
+
+func TestConfigDefaults(t *testing.T) {
+	usb := filepath.Join(t.TempDir(), "usb.db")
+	clip := 10 * time.Second
+
+	defer func() { cfg = &config.Config{} }()
+	cfg = &config.Config{
+		Store:            "usb",
+		Output:           "sh",
+		ClipboardTimeout: &clip,
+		Stores: map[string]config.Store{
+			"usb": {Path: usb, Secret: "env:USB_SECRET"},
+		},
+	}
+
+	t.Setenv(EnvSecret, "")
+	t.Setenv("USB_SECRET", testSecret)
+
+	// the default store and its secret
+	put := newCmdPut()
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	put.SetFlags(fs)
+	if err := fs.Parse([]string{"-n", testNamespace, "-k", "password", "magick"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := put.Execute(fs); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(usb); err != nil {
+		t.Fatal(err)
+	}
+
+	// the default output format and clipboard timeout
+	out := bytes.NewBufferString("")
+	get := newCmdGet()
+	fs = flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(out)
+	get.SetFlags(fs)
+	if err := fs.Parse([]string{"-n", testNamespace}); err != nil {
+		t.Fatal(err)
+	}
+	if got := fs.Lookup("clip-timeout").Value.String(); got != "10s" {
+		t.Fatalf("expected: 10s, got: %s", got)
+	}
+	if err := get.Execute(fs); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "export PASSWORD='magick'\n", out.String(); got != want {
+		t.Fatalf("expected: %q, got: %q", want, got)
+	}
+
+	// the flags win
+	get = newCmdGet()
+	fs = flag.NewFlagSet("", flag.ContinueOnError)
+	get.SetFlags(fs)
+	if err := fs.Parse([]string{"-n", testNamespace, "-s", "personal", "-o", "txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := get.storeRef.Name(); got != "personal" {
+		t.Fatalf("expected: personal, got: %s", got)
+	}
+}
+
+func TestReadSecretSource(t *testing.T) {
+	t.Setenv("MY_SECRET", "from env")
+
+	name := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(name, []byte("from file\nignored\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"env:MY_SECRET", "from env"},
+		{"file:" + name, "from file"},
+	}
+	if runtime.GOOS != "windows" {
+		tests = append(tests, struct {
+			src  string
+			want string
+		}{"cmd:echo 'from cmd'", "from cmd"})
+	}
+
+	for _, tc := range tests {
+		got, err := readSecretSource(tc.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Fatalf("%s: expected: %s, got: %s", tc.src, tc.want, got)
+		}
+	}
+
+	for _, src := range []string{"env:", "vault:x", "file:/does/not/exist"} {
+		if _, err := readSecretSource(src); err == nil {
+			t.Fatalf("%s: expected error", src)
+		}
+	}
+}
@@ -1,27 +1,61 @@
 package cmd
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
 	"github.com/lucasepe/locker/cmd/flags"
+	"github.com/lucasepe/locker/internal/kv"
 )
 
 func newCmdDelete() *cmdDelete {
 	return &cmdDelete{
 		namespace: flags.Namespace{},
-		key:       flags.Key{},
-		storeRef: flags.Store{
-			BaseDir: AppDir(),
-		},
+		key:       flags.KeyPattern{},
+		storeRef:  newStoreFlag(),
 	}
 }
 
 type cmdDelete struct {
 	namespace flags.Namespace
-	key       flags.Key
+	key       flags.KeyPattern
 	storeRef  flags.Store
+	yes       bool
+	dryRun    bool
+	output    string
+}
+
+// deleteResult is the structured output of delete.
+type deleteResult struct {
+	// Status is 'deleted' or, with -dry-run, 'not deleted'.
+	Status  string       `json:"status" yaml:"status"`
+	Deleted []deleteItem `json:"deleted" yaml:"deleted"`
+}
+
+// deleteItem is a deleted secret or, when the key is empty, namespace.
+type deleteItem struct {
+	Namespace string `json:"namespace" yaml:"namespace"`
+	Key       string `json:"key,omitempty" yaml:"key,omitempty"`
+	Secrets   int    `json:"secrets,omitempty" yaml:"secrets,omitempty"`
+}
+
+// deleteTarget is a secret to delete or, when the key is empty, a namespace.
+type deleteTarget struct {
+	namespace string
+	key       string
+	// count is the number of secrets in the namespace.
+	count int
+}
+
+func (t deleteTarget) String() string {
+	if len(t.key) == 0 {
+		return fmt.Sprintf("namespace '%s' (%d secrets)", t.namespace, t.count)
+	}
+	return fmt.Sprintf("secret (key: %s, namespace: %s)", t.key, t.namespace)
 }
 
 func (*cmdDelete) Name() string { return "delete" }
@@ -31,18 +65,30 @@ func (*cmdDelete) Synopsis() string {
 
 func (*cmdDelete) Usage() string {
 	return strings.ReplaceAll(`{NAME} delete [flags]
-  
+
    Delete the secret with key 'user' in the namespace 'google':
      {NAME} delete -n google -k user
 
    Delete the 'google' namespace:
-     {NAME} delete -n google`, "{NAME}", appLowerName)
+     {NAME} delete -n google
+
+   Delete the secrets with key starting with 'aws_' in all namespaces
+   (or only in one, with -n):
+     {NAME} delete -k 'aws_*'
+
+   Show what would be deleted, without deleting it:
+     {NAME} delete -k '*token*' -dry-run
+
+   When running in a terminal, the deletion is confirmed first (skip with -yes or -y).`, "{NAME}", appLowerName)
 }
 
 func (c *cmdDelete) SetFlags(fs *flag.FlagSet) {
-	fs.Var(&c.namespace, "n", "Namespace.")
-	fs.Var(&c.storeRef, "s", "Store name.")
-	fs.Var(&c.key, "k", "Secret key.")
+	fs.Var(&c.namespace, "n", "Namespace (all namespaces with a key pattern if omitted).")
+	fs.Var(&c.storeRef, "s", "Store name, alias or path.")
+	fs.Var(&c.key, "k", "Secret key or glob pattern (i.e. 'aws_*').")
+	fs.BoolVar(&c.yes, "yes", false, "Do not ask confirmation before deleting.")
+	fs.BoolVar(&c.yes, "y", false, "Alias of -yes.")
+	fs.BoolVar(&c.dryRun, "dry-run", false, "Print what would be deleted, without deleting it.")
 }
 
 func (c *cmdDelete) Execute(fs *flag.FlagSet) error {
@@ -56,26 +102,144 @@ func (c *cmdDelete) Execute(fs *flag.FlagSet) error {
 	}
 	defer sto.Close()
 
-	if len(c.key.Bytes()) > 0 {
-		err := sto.DeleteOne(c.namespace.String(), c.key.String())
-		if err == nil {
-			fmt.Fprintf(fs.Output(), "secret successfully deleted (key: %s, namespace: %s)\n",
-				c.key.String(), c.namespace.String())
+	targets, err := c.targets(sto)
+	if err != nil {
+		return err
+	}
+
+	res := deleteResult{Status: "deleted", Deleted: make([]deleteItem, len(targets))}
+	for i, el := range targets {
+		res.Deleted[i] = deleteItem{Namespace: el.namespace, Key: el.key, Secrets: el.count}
+	}
+
+	if c.dryRun {
+		if structured(c.output) {
+			res.Status = "not deleted"
+			return encodeOutput(fs.Output(), c.output, res)
+		}
+		for _, el := range targets {
+			fmt.Fprintf(fs.Output(), "would delete %s\n", el)
 		}
 		return nil
 	}
 
-	if err := sto.DeleteAll(c.namespace.String()); err == nil {
-		fmt.Fprintf(fs.Output(), "namespace '%s' successfully deleted\n", c.namespace.String())
+	if !c.yes {
+		if err := confirmDelete(os.Stderr, targets, confirm); err != nil {
+			return err
+		}
+	}
+
+	for _, el := range targets {
+		if len(el.key) == 0 {
+			if err := sto.DeleteAll(el.namespace); err != nil {
+				return fmt.Errorf("namespace %s: %w", el.namespace, err)
+			}
+			if err := recordAccess(sto, "delete", el.namespace); err != nil {
+				return err
+			}
+			if !structured(c.output) {
+				fmt.Fprintf(fs.Output(), "namespace '%s' successfully deleted\n", el.namespace)
+			}
+			continue
+		}
+
+		if err := sto.DeleteOne(el.namespace, el.key); err != nil {
+			return fmt.Errorf("key %s, namespace %s: %w", el.key, el.namespace, err)
+		}
+		if err := recordAccess(sto, "delete", el.namespace, el.key); err != nil {
+			return err
+		}
+		if !structured(c.output) {
+			fmt.Fprintf(fs.Output(), "secret successfully deleted (key: %s, namespace: %s)\n",
+				el.key, el.namespace)
+		}
+	}
+
+	if structured(c.output) {
+		return encodeOutput(fs.Output(), c.output, res)
 	}
 
 	return nil
 }
 
-func (c *cmdDelete) complete(fs *flag.FlagSet) error {
+// targets returns the namespace or the secrets to delete;
+// it fails if there is nothing to delete.
+func (c *cmdDelete) targets(sto kv.Store) ([]deleteTarget, error) {
+	namespaces := []string{c.namespace.String()}
 	if len(c.namespace.Bytes()) == 0 {
+		all, err := sto.Namespaces()
+		if err != nil {
+			return nil, err
+		}
+		namespaces = all
+	}
+
+	var res []deleteTarget
+	for _, ns := range namespaces {
+		keys, err := sto.Keys(ns)
+		if err != nil {
+			return nil, fmt.Errorf("namespace %s: %w", ns, err)
+		}
+
+		if len(c.key.Bytes()) == 0 {
+			res = append(res, deleteTarget{namespace: ns, count: len(keys)})
+			continue
+		}
+
+		for _, k := range keys {
+			if c.key.Match(k) {
+				res = append(res, deleteTarget{namespace: ns, key: k})
+			}
+		}
+	}
+
+	if len(res) == 0 {
+		if c.key.IsPattern() {
+			return nil, fmt.Errorf("no secrets match %s", c.key.String())
+		}
+		return nil, fmt.Errorf("key %s, namespace %s: %w", c.key.String(), c.namespace.String(), kv.ErrKeyNotFound)
+	}
+
+	return res, nil
+}
+
+// confirmDelete asks confirmation before deleting the targets, listed on w,
+// only when running in a terminal (ask fails with ErrNotTerminal otherwise).
+func confirmDelete(w io.Writer, targets []deleteTarget, ask func(question string) (bool, error)) error {
+	question := fmt.Sprintf("delete %s?", targets[0])
+	if len(targets) > 1 {
+		for _, el := range targets {
+			fmt.Fprintf(w, "  %s\n", el)
+		}
+		question = fmt.Sprintf("delete these %d secrets?", len(targets))
+	}
+
+	ok, err := ask(question)
+	if errors.Is(err, ErrNotTerminal) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("delete aborted")
+	}
+
+	return nil
+}
+
+func (c *cmdDelete) complete(fs *flag.FlagSet) error {
+	c.output = globalOutput()
+
+	if len(c.namespace.Bytes()) == 0 && !c.key.IsPattern() {
 		return fmt.Errorf("missing namespace")
 	}
 
+	pwd, err := getMasterSecret(&c.storeRef)
+	if err != nil {
+		return err
+	}
+	c.storeRef.MasterSecret = pwd
+
 	return nil
 }
@@ -2,11 +2,14 @@ package cmd
 
 import (
 	"bytes"
+	"errors"
 	"flag"
 	"io"
 	"os"
 	"strings"
 	"testing"
+
+	"github.com/lucasepe/locker/internal/kv"
 )
 
 func TestCmdDeleteOne(t *testing.T) {
@@ -61,6 +64,116 @@ func TestCmdDeleteAll(t *testing.T) {
 	}
 }
 
+func TestCmdDeleteNotFound(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runCmdDelete(out, "user"); !errors.Is(err, kv.ErrKeyNotFound) {
+		t.Fatalf("expected: %v, got: %v", kv.ErrKeyNotFound, err)
+	}
+
+	err := runCmdDeleteArgs(out, "-n", "nowhere")
+	if !errors.Is(err, kv.ErrNamespaceNotFound) {
+		t.Fatalf("expected: %v, got: %v", kv.ErrNamespaceNotFound, err)
+	}
+
+	if err := runCmdDeleteArgs(out, "-k", "aws_*"); err == nil {
+		t.Fatal("expected error when no secrets match")
+	}
+}
+
+func TestConfirmDelete(t *testing.T) {
+	targets := []deleteTarget{
+		{namespace: "google", count: 2},
+		{namespace: "aws", key: "token"},
+	}
+
+	var question string
+	out := bytes.NewBufferString("")
+	err := confirmDelete(out, targets, func(q string) (bool, error) {
+		question = q
+		return false, nil
+	})
+	if err == nil || err.Error() != "delete aborted" {
+		t.Fatalf("expected delete aborted, got: %v", err)
+	}
+	if question != "delete these 2 secrets?" {
+		t.Fatalf("unexpected question: %s", question)
+	}
+
+	want := "  namespace 'google' (2 secrets)\n  secret (key: token, namespace: aws)\n"
+	if out.String() != want {
+		t.Fatalf("expected: %q, got: %q", want, out.String())
+	}
+
+	// not running in a terminal
+	err = confirmDelete(out, targets, func(string) (bool, error) {
+		return false, ErrNotTerminal
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCmdDeletePattern(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	for _, el := range [][]string{
+		{"google", "api_token", "1"},
+		{"google", "user", "2"},
+		{"github", "api_token", "3"},
+		{"github", "password", "4"},
+	} {
+		if err := runCmdPutArgs(out, "-n", el[0], "-k", el[1], el[2]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out.Reset()
+	if err := runCmdDeleteArgs(out, "-k", "*token*", "-dry-run"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "would delete secret (key: api_token, namespace: github)\n" +
+		"would delete secret (key: api_token, namespace: google)\n"
+	if got := out.String(); got != want {
+		t.Fatalf("expected: %q, got: %q", want, got)
+	}
+
+	out.Reset()
+	if err := runCmdListNamespace(out, "github"); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, "api_token") {
+		t.Fatalf("dry-run deleted the secrets: %s", got)
+	}
+
+	out.Reset()
+	if err := runCmdDeleteArgs(out, "-k", "*token*", "--yes"); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(out.String(), "secret successfully deleted"); got != 2 {
+		t.Fatalf("expected 2 secrets deleted, got: %s", out.String())
+	}
+
+	out.Reset()
+	if err := runCmdListNamespace(out, "github"); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); strings.Contains(got, "api_token") || !strings.Contains(got, "password") {
+		t.Fatalf("unexpected keys: %s", got)
+	}
+}
+
 func runCmdDelete(output io.Writer, key string) error {
 	op := newCmdDelete()
 
@@ -83,3 +196,18 @@ func runCmdDelete(output io.Writer, key string) error {
 
 	return op.Execute(fs)
 }
+
+func runCmdDeleteArgs(output io.Writer, args ...string) error {
+	op := newCmdDelete()
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	op.SetFlags(fs)
+
+	if err := fs.Parse(append([]string{"-s", testStore}, args...)); err != nil {
+		return err
+	}
+
+	return op.Execute(fs)
+}
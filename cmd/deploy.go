@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The get output formats for deployments.
+const (
+	fmtK8sSecret = "k8s-secret"
+	fmtCompose   = "compose"
+	fmtSystemd   = "systemd"
+)
+
+var (
+	// k8sNameRe matches the DNS subdomain names of the Kubernetes objects.
+	k8sNameRe = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	// k8sKeyRe matches the keys of the Secret data.
+	k8sKeyRe = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+)
+
+// k8sSecretHeader writes the v1/Secret manifest up to the data field.
+func (c *cmdGet) k8sSecretHeader(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "apiVersion: v1\nkind: Secret\nmetadata:\n  name: %s\ntype: Opaque\ndata:\n",
+		c.secretName())
+	return err
+}
+
+// secretName returns the name of the Secret (the namespace by default).
+func (c *cmdGet) secretName() string {
+	if len(c.name) > 0 {
+		return c.name
+	}
+	return c.namespace.String()
+}
+
+// checkK8sKey fails if the key is not a valid Kubernetes Secret key.
+func checkK8sKey(key string) error {
+	if !k8sKeyRe.MatchString(key) {
+		return fmt.Errorf("key %s: not a valid Kubernetes Secret key", key)
+	}
+	return nil
+}
+
+// exportK8sSecret writes the value, base64 encoded, as a field of the Secret data.
+func exportK8sSecret(w io.Writer, key, val string) error {
+	if err := checkK8sKey(key); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "  %s: %q", yamlScalar(key), base64.StdEncoding.EncodeToString([]byte(val)))
+	return err
+}
+
+// composeHeader writes the top-level secrets element of a compose file.
+func composeHeader(w io.Writer) error {
+	_, err := io.WriteString(w, "secrets:\n")
+	return err
+}
+
+// exportCompose writes the value to a file in the directory and the
+// compose secret referencing it.
+func (c *cmdGet) exportCompose(w io.Writer, key, val string) error {
+	name, err := c.writeSecretFile(key, val)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "  %s:\n    file: %s", yamlScalar(key), yamlScalar(filepath.ToSlash(name)))
+	return err
+}
+
+// exportSystemd writes the value to a file in the directory and the
+// LoadCredential= setting of the unit loading it.
+func (c *cmdGet) exportSystemd(w io.Writer, key, val string) error {
+	if err := checkCredentialName(key); err != nil {
+		return err
+	}
+
+	name, err := c.writeSecretFile(key, val)
+	if err != nil {
+		return err
+	}
+
+	// systemd requires absolute paths
+	name, err = filepath.Abs(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "LoadCredential=%s:%s", key, name)
+	return err
+}
+
+// writeSecretFile writes the value to a file, named as the key,
+// readable only by the owner; it returns the file name.
+func (c *cmdGet) writeSecretFile(key, val string) (string, error) {
+	if err := checkFileName(key); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return "", err
+	}
+
+	name := filepath.Join(c.dir, key)
+	return name, writePrivateFile(name, []byte(val))
+}
+
+// checkFileName fails if the key cannot be used as a file name.
+func checkFileName(key string) error {
+	if key == "." || key == ".." || strings.ContainsAny(key, `/\`) {
+		return fmt.Errorf("key %s: not a valid file name", key)
+	}
+	return nil
+}
+
+// checkCredentialName fails if the key is not a valid systemd credential
+// name: a file name of at most 255 printable ASCII characters, but ':'.
+func checkCredentialName(key string) error {
+	if err := checkFileName(key); err != nil {
+		return err
+	}
+
+	valid := len(key) > 0 && len(key) <= 255
+	for _, r := range key {
+		if r < ' ' || r > '~' || r == ':' {
+			valid = false
+		}
+	}
+	if !valid {
+		return fmt.Errorf("key %s: not a valid systemd credential name", key)
+	}
+
+	return nil
+}
+
+// yamlScalar returns the string as a YAML scalar, quoted when needed
+// (i.e. 'yes' or '123' which would be read as a bool or a number).
+func yamlScalar(s string) string {
+	dat, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Sprintf("%q", s)
+	}
+	return strings.TrimSuffix(string(dat), "\n")
+}
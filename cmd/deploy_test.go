@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+)
+
+func TestCmdGetK8sSecret(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "user name", "pinco pallo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCmdPut(out, "password", "it's \"magick\"\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := runCmdGetArgs(out, "-o", "k8s-secret", "-name", "app-creds"); err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		APIVersion string `yaml:"apiVersion"`
+		Kind       string `yaml:"kind"`
+		Metadata   struct {
+			Name string `yaml:"name"`
+		} `yaml:"metadata"`
+		Type string            `yaml:"type"`
+		Data map[string]string `yaml:"data"`
+	}
+	if err := yaml.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.APIVersion != "v1" || got.Kind != "Secret" || got.Metadata.Name != "app-creds" || got.Type != "Opaque" {
+		t.Fatalf("unexpected manifest:\n%s", out.String())
+	}
+
+	want := map[string]string{"password": "it's \"magick\"\n", "user_name": "pinco pallo"}
+	for k, v := range got.Data {
+		dat, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got.Data[k] = string(dat)
+	}
+	if !cmp.Equal(want, got.Data) {
+		t.Fatal(cmp.Diff(want, got.Data))
+	}
+
+	if err := runCmdGetArgs(out, "-o", "k8s-secret", "-name", "App_Creds"); err == nil {
+		t.Fatal("expected error for an invalid Secret name")
+	}
+}
+
+func TestCmdGetSecretFiles(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "secrets")
+	name := filepath.Join(dir, "password")
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"compose", fmt.Sprintf("secrets:\n  password:\n    file: %s\n", filepath.ToSlash(name))},
+		{"systemd", fmt.Sprintf("LoadCredential=password:%s\n", name)},
+	}
+
+	for _, tc := range tests {
+		os.RemoveAll(dir)
+
+		out.Reset()
+		if err := runCmdGetArgs(out, "-o", tc.format, "-dir", dir); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := out.String(); got != tc.want {
+			t.Fatalf("%s: expected: %q, got: %q", tc.format, tc.want, got)
+		}
+
+		dat, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(dat) != "magick" {
+			t.Fatalf("%s: expected: magick, got: %s", tc.format, dat)
+		}
+
+		if fi, _ := os.Stat(name); runtime.GOOS != "windows" && fi.Mode().Perm() != 0600 {
+			t.Fatalf("%s: expected mode 0600, got: %v", tc.format, fi.Mode().Perm())
+		}
+	}
+
+	if err := runCmdGetArgs(out, "-o", "systemd"); err == nil {
+		t.Fatal("expected error for the missing directory")
+	}
+}
+
+func TestCmdGetSystemdInvalidName(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCmdPut(out, strings.Repeat("z", 256), "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "secrets")
+	if err := runCmdGetArgs(out, "-o", "systemd", "-dir", dir); err == nil {
+		t.Fatal("expected error for an invalid credential name")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "password")); !os.IsNotExist(err) {
+		t.Fatalf("expected no credential file, got: %v", err)
+	}
+}
+
+func TestCheckCredentialName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"password", true},
+		{"db password", true},
+		{"db:password", false},
+		{"", false},
+		{".", false},
+		{"a/b", false},
+		{"pass\tword", false},
+		{"pässword", false},
+		{strings.Repeat("a", 255), true},
+		{strings.Repeat("a", 256), false},
+	}
+
+	for _, tc := range tests {
+		if err := checkCredentialName(tc.name); (err == nil) != tc.valid {
+			t.Fatalf("%q: expected valid: %t, got: %v", tc.name, tc.valid, err)
+		}
+	}
+}
+
+func runCmdGetArgs(output io.Writer, args ...string) error {
+	op := newCmdGet()
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	op.SetFlags(fs)
+
+	if err := fs.Parse(append([]string{"-n", testNamespace, "-s", testStore}, args...)); err != nil {
+		return err
+	}
+
+	return op.Execute(fs)
+}
@@ -0,0 +1,335 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/lucasepe/locker/cmd/flags"
+	"github.com/lucasepe/locker/internal/kv"
+)
+
+func newCmdEdit() *cmdEdit {
+	return &cmdEdit{
+		namespace: flags.Namespace{},
+		key:       flags.Key{},
+		storeRef:  newStoreFlag(),
+		// tmpfs first, so that the secret never reaches the disk
+		tempDirs: []string{"/dev/shm", os.TempDir()},
+	}
+}
+
+type cmdEdit struct {
+	namespace flags.Namespace
+	key       flags.Key
+	storeRef  flags.Store
+	tempDirs  []string
+	output    string
+}
+
+func (*cmdEdit) Name() string { return "edit" }
+func (*cmdEdit) Synopsis() string {
+	return "Edit a secret with your text editor."
+}
+
+func (*cmdEdit) Usage() string {
+	return strings.ReplaceAll(`{NAME} edit [flags]
+
+   Edit the secret with key 'recovery codes' in the 'google' namespace using $EDITOR:
+     {NAME} edit -n google -k 'recovery codes'
+
+   The secret is written to a private temporary file (on /dev/shm when available)
+   which is overwritten and removed as soon as the editor exits.`, "{NAME}", appLowerName)
+}
+
+func (c *cmdEdit) SetFlags(fs *flag.FlagSet) {
+	fs.Var(&c.namespace, "n", "Namespace.")
+	fs.Var(&c.storeRef, "s", "Store name, alias or path.")
+	fs.Var(&c.key, "k", "Secret key.")
+}
+
+func (c *cmdEdit) Execute(fs *flag.FlagSet) error {
+	if err := c.complete(fs); err != nil {
+		return err
+	}
+
+	editor, err := editorCommand()
+	if err != nil {
+		return err
+	}
+
+	sto, err := c.storeRef.Connect()
+	if err != nil {
+		return err
+	}
+	defer sto.Close()
+
+	namespace, key := c.namespace.String(), c.key.String()
+
+	// a missing key is a new secret
+	val, err := sto.GetOne(namespace, key)
+	if err != nil && !errors.Is(err, kv.ErrNamespaceNotFound) {
+		return err
+	}
+
+	dir, err := c.tempDir()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := shredDir(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "warn: the secret may be left in %s: %s\n", dir, err.Error())
+		}
+	}()
+
+	// a random name, not to reveal the secret key
+	// (the extension is for syntax highlighting)
+	fp, err := os.CreateTemp(dir, "*.txt")
+	if err != nil {
+		return err
+	}
+	name := fp.Name()
+	_, err = fp.WriteString(val)
+	if cerr := fp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := runEditor(editor, name); err != nil {
+		return fmt.Errorf("secret not changed: %w", err)
+	}
+
+	dat, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	// editors usually add a final newline
+	if !strings.HasSuffix(val, "\n") {
+		dat = bytes.TrimSuffix(dat, []byte("\n"))
+	}
+
+	res := statusResult{
+		Status:    "unchanged",
+		Store:     filepath.Base(c.storeRef.String()),
+		Namespace: namespace,
+		Key:       key,
+	}
+
+	if string(dat) == val {
+		if structured(c.output) {
+			return encodeOutput(fs.Output(), c.output, res)
+		}
+		fmt.Fprintf(fs.Output(), "secret not changed (key: %s, namespace: %s)\n", key, namespace)
+		return nil
+	}
+
+	if len(dat) == 0 {
+		return fmt.Errorf("secret not changed: the new value is empty (use delete to remove it)")
+	}
+
+	if err := sto.PutOne(namespace, key, string(dat)); err != nil {
+		return err
+	}
+
+	if err := recordAccess(sto, "edit", namespace, key); err != nil {
+		return err
+	}
+
+	if structured(c.output) {
+		res.Status = "stored"
+		return encodeOutput(fs.Output(), c.output, res)
+	}
+
+	fmt.Fprintf(fs.Output(), "secret successfully stored (key:%s, namespace: %s, store: %s)\n",
+		key, namespace, filepath.Base(c.storeRef.String()))
+
+	return nil
+}
+
+// tempDir creates a private directory in the first usable temporary directory.
+func (c *cmdEdit) tempDir() (dir string, err error) {
+	for _, el := range c.tempDirs {
+		dir, err = os.MkdirTemp(el, appLowerName+"-")
+		if err == nil {
+			return dir, nil
+		}
+	}
+	return "", err
+}
+
+func (c *cmdEdit) complete(fs *flag.FlagSet) error {
+	c.output = globalOutput()
+
+	if len(c.namespace.Bytes()) == 0 {
+		return fmt.Errorf("missing namespace")
+	}
+
+	if len(c.key.Bytes()) == 0 {
+		return fmt.Errorf("missing key")
+	}
+
+	pwd, err := getMasterSecret(&c.storeRef)
+	if err != nil {
+		return err
+	}
+	c.storeRef.MasterSecret = pwd
+
+	return nil
+}
+
+// editorCommand returns the command line of the editor
+// set in $VISUAL or $EDITOR (vi or notepad by default).
+func editorCommand() ([]string, error) {
+	editor := os.Getenv("VISUAL")
+	if len(editor) == 0 {
+		editor = os.Getenv("EDITOR")
+	}
+	if len(editor) == 0 {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	args, err := editorFields(editor)
+	if err != nil {
+		return nil, fmt.Errorf("editor %q: %w", editor, err)
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("editor not specified")
+	}
+
+	return args, nil
+}
+
+// editorFields splits the editor command line into words like sh does:
+// unlike shellFields, within double quotes a backslash escapes only
+// the characters " \ $ ` (i.e. sh -c "printf 'a\n' > \"$0\"").
+func editorFields(line string) ([]string, error) {
+	var res []string
+	var sb strings.Builder
+	var quote rune
+	inWord, escaped := false, false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune("\"\\$`", r) {
+				sb.WriteRune('\\')
+			}
+			sb.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				sb.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				res = append(res, sb.String())
+				sb.Reset()
+				inWord = false
+			}
+		default:
+			sb.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape")
+	}
+	if inWord {
+		res = append(res, sb.String())
+	}
+
+	return res, nil
+}
+
+// runEditor runs the editor on the file; the termination signals
+// are caught so that the caller can always clean up.
+func runEditor(editor []string, name string) error {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+
+	cmd := exec.Command(editor[0], append(editor[1:], name)...)
+	// editor plugins must not get the master secrets
+	cmd.Env = childEnviron()
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	go func() {
+		for sig := range sigs {
+			cmd.Process.Signal(sig)
+		}
+	}()
+
+	return cmd.Wait()
+}
+
+// shredDir overwrites with zeros all the files in the directory
+// (i.e. also the editor backup files) and removes it;
+// it returns the first error, so that the user can be warned.
+func shredDir(dir string) error {
+	var res error
+	setErr := func(err error) {
+		if res == nil {
+			res = err
+		}
+	}
+
+	filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			setErr(err)
+			return nil
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		fp, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			setErr(err)
+			return nil
+		}
+		if _, err := fp.Write(make([]byte, fi.Size())); err != nil {
+			setErr(err)
+		}
+		if err := fp.Sync(); err != nil {
+			setErr(err)
+		}
+		if err := fp.Close(); err != nil {
+			setErr(err)
+		}
+
+		return nil
+	})
+
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+
+	return res
+}
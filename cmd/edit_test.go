@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCmdEdit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake editor is a shell command")
+	}
+
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "recovery codes", "1234"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		editor  string
+		wantErr bool
+		want    string
+		output  string
+	}{
+		{
+			editor: `sh -c "printf '1234\n5678\n' > \"\$0\""`,
+			want:   "1234\n5678",
+			output: "secret successfully stored (key:recovery_codes, namespace: stuffs, store: test.db)\n",
+		},
+		{
+			editor: `sh -c "echo >> \"\$0\""`,
+			want:   "1234\n5678",
+			output: "secret not changed (key: recovery_codes, namespace: stuffs)\n",
+		},
+		{
+			editor:  `sh -c "echo lost > \"\$0\"; exit 3"`,
+			wantErr: true,
+			want:    "1234\n5678",
+		},
+		{
+			// the editor does not get the master secret
+			editor: `sh -c "printf 'x%s' \"\$LOCKER_SECRET\" > \"\$0\""`,
+			want:   "x",
+			output: "secret successfully stored (key:recovery_codes, namespace: stuffs, store: test.db)\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Setenv("VISUAL", "")
+		t.Setenv("EDITOR", tc.editor)
+
+		tmp := t.TempDir()
+
+		out.Reset()
+		err := runCmdEdit(out, tmp, "recovery codes")
+		if tc.wantErr != (err != nil) {
+			t.Fatalf("%s: unexpected error: %v", tc.editor, err)
+		}
+		if got := out.String(); got != tc.output {
+			t.Fatalf("%s: expected: %q, got: %q", tc.editor, tc.output, got)
+		}
+
+		// the temporary files have been removed
+		if all, _ := os.ReadDir(tmp); len(all) != 0 {
+			t.Fatalf("%s: temporary files left: %v", tc.editor, all)
+		}
+
+		out.Reset()
+		if err := runCmdGet(out, "recovery_codes"); err != nil {
+			t.Fatal(err)
+		}
+		if got := out.String(); got != tc.want {
+			t.Fatalf("%s: expected: %q, got: %q", tc.editor, tc.want, got)
+		}
+	}
+}
+
+func TestCmdEditTempName(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake editor is a shell command")
+	}
+
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	// the editor stores the name of the file
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", `sh -c "basename \"\$0\" > \"\$0\""`)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdEdit(out, t.TempDir(), "recovery codes"); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := runCmdGet(out, "recovery_codes"); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); strings.Contains(got, "recovery") || !strings.HasSuffix(got, ".txt") {
+		t.Fatalf("expected a random .txt file name, got: %s", got)
+	}
+}
+
+func TestEditorFields(t *testing.T) {
+	got, err := editorFields(`code -w "my dir" a\ b "x\n\"y\""`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"code", "-w", "my dir", "a b", `x\n"y"`}
+	if !cmp.Equal(want, got) {
+		t.Fatal(cmp.Diff(want, got))
+	}
+}
+
+func runCmdEdit(output io.Writer, tmp, key string) error {
+	op := newCmdEdit()
+	op.tempDirs = []string{tmp}
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	op.SetFlags(fs)
+
+	args := []string{
+		"-n", testNamespace,
+		"-s", testStore,
+		"-k", key,
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return op.Execute(fs)
+}
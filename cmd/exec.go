@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/lucasepe/locker/cmd/flags"
+	"github.com/lucasepe/locker/internal/agent"
+	"github.com/lucasepe/locker/internal/envvar"
+	"github.com/lucasepe/strcase"
+)
+
+func newCmdExec() *cmdExec {
+	return &cmdExec{
+		namespaces: flags.NamespaceList{},
+		prefixes:   flags.StringList{},
+		mappings:   flags.StringList{},
+		storeRef:   newStoreFlag(),
+	}
+}
+
+type cmdExec struct {
+	namespaces flags.NamespaceList
+	prefixes   flags.StringList
+	mappings   flags.StringList
+	storeRef   flags.Store
+}
+
+func (*cmdExec) Name() string { return "exec" }
+func (*cmdExec) Synopsis() string {
+	return "Run a command with the secrets of some namespaces as environment variables."
+}
+
+func (*cmdExec) Usage() string {
+	return strings.ReplaceAll(`{NAME} exec [flags] -- <command> [args...]
+  
+   Run 'deploy.sh' with all the secrets in the 'db' and 'aws' namespaces:
+     {NAME} exec -n db -n aws -- ./deploy.sh
+
+   Prefix the variables of the 'db' namespace with 'DB_' (i.e. DB_PASSWORD):
+     {NAME} exec -n db -p db=DB_ -- ./deploy.sh
+
+   Export the 'password' key of the 'db' namespace as 'PGPASSWORD':
+     {NAME} exec -n db -m db.password=PGPASSWORD -- psql`, "{NAME}", appLowerName)
+}
+
+func (c *cmdExec) SetFlags(fs *flag.FlagSet) {
+	fs.Var(&c.namespaces, "n", "Namespace (repeatable).")
+	fs.Var(&c.storeRef, "s", "Store name, alias or path.")
+	fs.Var(&c.prefixes, "p", "Variable names prefix, for all namespaces (PREFIX) or one (namespace=PREFIX); repeatable.")
+	fs.Var(&c.mappings, "m", "Variable name for a key (key=NAME or namespace.key=NAME); repeatable.")
+}
+
+func (c *cmdExec) Execute(fs *flag.FlagSet) error {
+	if err := c.complete(fs); err != nil {
+		return err
+	}
+
+	vars, err := c.environ()
+	if err != nil {
+		return err
+	}
+
+	child := exec.Command(fs.Arg(0), fs.Args()[1:]...)
+	child.Env = append(childEnviron(), vars...)
+	child.Stdin = os.Stdin
+	child.Stdout = fs.Output()
+	child.Stderr = os.Stderr
+
+	if err := child.Start(); err != nil {
+		return err
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+	defer signal.Stop(sigs)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		for {
+			select {
+			case sig := <-sigs:
+				child.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err = child.Wait()
+
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		return &ExitError{Code: ee.ExitCode()}
+	}
+
+	return err
+}
+
+// environ decrypts the namespaces and returns their secrets
+// as a list of NAME=value strings.
+func (c *cmdExec) environ() ([]string, error) {
+	sto, err := c.storeRef.Connect()
+	if err != nil {
+		return nil, err
+	}
+	// the store is released before running the command
+	defer sto.Close()
+
+	prefixes := map[string]string{}
+	for _, el := range c.prefixes.Values() {
+		ns, prefix, ok := strings.Cut(el, "=")
+		if !ok {
+			ns, prefix = "", el
+		}
+		prefixes[strcase.Kebab(ns)] = prefix
+	}
+
+	mappings := map[string]string{}
+	for _, el := range c.mappings.Values() {
+		ref, name, ok := strings.Cut(el, "=")
+		if !ok || len(name) == 0 {
+			return nil, fmt.Errorf("invalid mapping: %s (expected key=NAME)", el)
+		}
+		if ns, key, ok := strings.Cut(ref, "."); ok {
+			ref = strcase.Kebab(ns) + "." + strcase.Snake(key)
+		} else {
+			ref = strcase.Snake(ref)
+		}
+		mappings[ref] = name
+	}
+
+	var res []string
+	for _, ns := range c.namespaces.Values() {
+		all, err := sto.GetAll(ns)
+		if err != nil {
+			return nil, fmt.Errorf("namespace: %s: %w", ns, err)
+		}
+
+		keys := make([]string, 0, len(all))
+		for k := range all {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		if err := recordAccess(sto, "exec", ns, keys...); err != nil {
+			return nil, err
+		}
+
+		prefix, ok := prefixes[ns]
+		if !ok {
+			prefix = prefixes[""]
+		}
+
+		for _, k := range keys {
+			name, ok := mappings[ns+"."+k]
+			if !ok {
+				name, ok = mappings[k]
+			}
+			if !ok {
+				name = envvar.Name(prefix, k)
+			}
+			res = append(res, fmt.Sprintf("%s=%s", name, all[k]))
+		}
+	}
+
+	return res, nil
+}
+
+func (c *cmdExec) complete(fs *flag.FlagSet) error {
+	if len(c.namespaces.Values()) == 0 {
+		return fmt.Errorf("missing namespace")
+	}
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("missing command to run")
+	}
+
+	pwd, err := getMasterSecret(&c.storeRef)
+	if err != nil {
+		return err
+	}
+	c.storeRef.MasterSecret = pwd
+
+	return nil
+}
+
+// childEnviron returns the current environment without the master secrets
+// (LOCKER_SECRET, LOCKER_SECRET_<STORE> and the env: sources of the
+// configuration), the agent socket and the named variables.
+func childEnviron(names ...string) []string {
+	names = append(names, EnvSecret, agent.EnvSock)
+	for _, el := range cfg.Stores {
+		if kind, arg, _ := strings.Cut(el.Secret, ":"); kind == "env" {
+			names = append(names, arg)
+		}
+	}
+
+	var res []string
+	for _, el := range os.Environ() {
+		name, _, _ := strings.Cut(el, "=")
+		if !contains(names, name) && !strings.HasPrefix(name, EnvSecret+"_") {
+			res = append(res, el)
+		}
+	}
+	return res
+}
+
+func contains(s []string, e string) bool {
+	for _, v := range s {
+		if v == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/lucasepe/locker/internal/config"
+)
+
+func TestCmdExec(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "user name", "pinco pallo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCmdPut(out, "password", "it's a\nmagick"); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	err := runCmdExec(out, []string{"-p", "APP_", "-m", "stuffs.password=PGPASSWORD"},
+		"sh", "-c", `printf '%s|%s|%s' "$APP_USER_NAME" "$PGPASSWORD" "$LOCKER_SECRET"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := out.String()
+	want := "pinco pallo|it's a\nmagick|"
+	if got != want {
+		t.Fatalf("expected: %q, got: %q", want, got)
+	}
+}
+
+func TestCmdExecSecrets(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	cfg = &config.Config{Stores: map[string]config.Store{
+		"team": {Secret: "env:TEAM_SECRET"},
+	}}
+	defer func() { cfg = &config.Config{} }()
+
+	os.Setenv(EnvSecret, testSecret)
+	t.Setenv("LOCKER_SECRET_WORK", "work secret")
+	t.Setenv("TEAM_SECRET", "team secret")
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	err := runCmdExec(out, nil,
+		"sh", "-c", `printf '%s|%s|%s' "$LOCKER_SECRET" "$LOCKER_SECRET_WORK" "$TEAM_SECRET"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "||" {
+		t.Fatalf("expected no master secrets, got: %q", got)
+	}
+}
+
+func TestCmdExecExitCode(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runCmdExec(out, nil, "sh", "-c", "exit 3")
+
+	var ee *ExitError
+	if !errors.As(err, &ee) || ee.Code != 3 {
+		t.Fatalf("expected exit code 3, got: %v", err)
+	}
+}
+
+func runCmdExec(output io.Writer, extra []string, command ...string) error {
+	op := newCmdExec()
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	op.SetFlags(fs)
+
+	args := []string{
+		"-n", testNamespace,
+		"-s", testStore,
+	}
+	args = append(args, extra...)
+	args = append(args, "--")
+	args = append(args, command...)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return op.Execute(fs)
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lucasepe/locker/cmd/flags"
+	"github.com/lucasepe/locker/internal/secrets"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	fmtYAML = "yaml"
+	fmtJSON = "json"
+
+	// exportBlockType is the PEM block type of encrypted exports.
+	exportBlockType = "LOCKER EXPORT"
+)
+
+func newCmdExport() *cmdExport {
+	return &cmdExport{
+		namespaces: flags.NamespaceList{},
+		file:       flags.FileFlag{},
+		storeRef:   newStoreFlag(),
+		output:     flags.Enum{Choices: []string{fmtYAML, fmtJSON}},
+	}
+}
+
+type cmdExport struct {
+	namespaces flags.NamespaceList
+	file       flags.FileFlag
+	storeRef   flags.Store
+	output     flags.Enum
+	encrypt    bool
+	yes        bool
+	// status is the output format of the result, when writing to a file.
+	status string
+}
+
+// exportResult is the structured output of export, when writing to a file.
+type exportResult struct {
+	Status     string   `json:"status" yaml:"status"`
+	File       string   `json:"file" yaml:"file"`
+	Namespaces []string `json:"namespaces" yaml:"namespaces"`
+}
+
+func (*cmdExport) Name() string { return "export" }
+func (*cmdExport) Synopsis() string {
+	return "Export one, some or all namespaces."
+}
+
+func (*cmdExport) Usage() string {
+	return strings.ReplaceAll(`{NAME} export [flags]
+  
+   Export all namespaces to an encrypted file:
+     {NAME} export -encrypt -f backup.pem
+
+   Export the 'google' and 'yahoo' namespaces as JSON:
+     {NAME} export -n google -n yahoo -o json
+
+   Exported files can be imported back:
+     {NAME} import -f backup.pem`, "{NAME}", appLowerName)
+}
+
+func (c *cmdExport) SetFlags(fs *flag.FlagSet) {
+	fs.Var(&c.namespaces, "n", "Namespace (repeatable, all namespaces if omitted).")
+	fs.Var(&c.storeRef, "s", "Store name, alias or path.")
+	fs.Var(&c.file, "f", "Output file (stdout if omitted).")
+	fs.Var(&c.output, "o", fmt.Sprintf("Output format, one of: %s", strings.Join(c.output.Choices, ",")))
+	fs.BoolVar(&c.encrypt, "encrypt", false, "Encrypt the output with the master secret.")
+	fs.BoolVar(&c.yes, "y", false, "Do not ask confirmation before writing plaintext secrets to disk.")
+}
+
+func (c *cmdExport) Execute(fs *flag.FlagSet) error {
+	if err := c.complete(fs); err != nil {
+		return err
+	}
+
+	sto, err := c.storeRef.Connect()
+	if err != nil {
+		return err
+	}
+	defer sto.Close()
+
+	names := c.namespaces.Values()
+	if len(names) == 0 {
+		names, err = sto.Namespaces()
+		if err != nil {
+			return err
+		}
+	}
+
+	docs := make([]SecretList, 0, len(names))
+	for _, ns := range names {
+		all, err := sto.GetAll(ns)
+		if err != nil {
+			return fmt.Errorf("namespace: %s: %w", ns, err)
+		}
+
+		doc := SecretList{Namespace: ns}
+		for k, v := range all {
+			doc.Secrets = append(doc.Secrets, Secret{Key: k, Value: v})
+		}
+		sort.Slice(doc.Secrets, func(i, j int) bool {
+			return doc.Secrets[i].Key < doc.Secrets[j].Key
+		})
+
+		keys := make([]string, len(doc.Secrets))
+		for i, el := range doc.Secrets {
+			keys[i] = el.Key
+		}
+		if err := recordAccess(sto, "export", ns, keys...); err != nil {
+			return err
+		}
+
+		docs = append(docs, doc)
+	}
+
+	dat, err := c.encode(docs)
+	if err != nil {
+		return err
+	}
+
+	if len(c.file.String()) == 0 {
+		_, err = fs.Output().Write(dat)
+		return err
+	}
+
+	if err := writePrivateFile(c.file.String(), dat); err != nil {
+		return err
+	}
+
+	if structured(c.status) {
+		return encodeOutput(fs.Output(), c.status, exportResult{
+			Status:     "exported",
+			File:       c.file.String(),
+			Namespaces: names,
+		})
+	}
+
+	fmt.Fprintf(fs.Output(), "successfully exported %d namespaces to %s\n", len(docs), c.file.String())
+
+	return nil
+}
+
+func (c *cmdExport) encode(docs []SecretList) ([]byte, error) {
+	var buf bytes.Buffer
+	switch c.output.Value {
+	case fmtJSON:
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(docs); err != nil {
+			return nil, err
+		}
+	default:
+		for _, doc := range docs {
+			buf.WriteString("---\n")
+			enc := yaml.NewEncoder(&buf)
+			enc.SetIndent(2)
+			if err := enc.Encode(doc); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !c.encrypt {
+		return buf.Bytes(), nil
+	}
+
+	dat, err := secrets.Encrypt([]byte(c.storeRef.MasterSecret), buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:    exportBlockType,
+		Headers: map[string]string{"Format": c.output.Value},
+		Bytes:   dat,
+	}), nil
+}
+
+func (c *cmdExport) complete(fs *flag.FlagSet) error {
+	// the global output format is also the one of the exported secrets
+	c.status = globalOutput()
+	if c.output.Value == "" {
+		c.output.Set(fmtYAML)
+		if structured(c.status) {
+			c.output.Set(c.status)
+		}
+	}
+
+	if len(c.file.String()) > 0 && !c.encrypt && !c.yes {
+		ok, err := confirm(fmt.Sprintf("write plaintext secrets to %s?", c.file.String()))
+		if err != nil {
+			return fmt.Errorf("refusing to write plaintext secrets to disk, use -encrypt or -y")
+		}
+		if !ok {
+			return fmt.Errorf("export aborted")
+		}
+	}
+
+	pwd, err := getMasterSecret(&c.storeRef)
+	if err != nil {
+		return err
+	}
+	// the agent never shares the master secret
+	if c.encrypt && len(pwd) == 0 {
+		return fmt.Errorf("the master secret is needed to encrypt: %w", ErrUnsetMasterSecret)
+	}
+	c.storeRef.MasterSecret = pwd
+
+	return nil
+}
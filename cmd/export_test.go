@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCmdExport(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "user name", "pinco.pallo@gmail.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := runCmdExport(out, "-n", testNamespace); err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.TrimSpace(out.String())
+	want := `---
+namespace: stuffs
+secrets:
+  - key: password
+    value: magick
+  - key: user_name
+    value: pinco.pallo@gmail.com`
+	if got != want {
+		t.Fatalf("expected: %v, got: %v", want, got)
+	}
+}
+
+func TestCmdExportRoundTrip(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdImport(out); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []string{fmtYAML, fmtJSON} {
+		dst := filepath.Join(t.TempDir(), "backup.pem")
+
+		out.Reset()
+		if err := runCmdExport(out, "-o", format, "-encrypt", "-f", dst); err != nil {
+			t.Fatal(err)
+		}
+
+		dat, err := os.ReadFile(dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(dat, []byte("pinco.pallo@gmail.com")) {
+			t.Fatalf("expected encrypted export, got: %s", dat)
+		}
+
+		out.Reset()
+		if err := runCmdImportFile(out, dst); err != nil {
+			t.Fatal(err)
+		}
+
+		got := strings.TrimSpace(out.String())
+		want := "successfully imported 2 documents"
+		if got != want {
+			t.Fatalf("expected: %s, got: %s", want, got)
+		}
+	}
+}
+
+func TestCmdExportRefusesPlaintext(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	dst := filepath.Join(t.TempDir(), "backup.yaml")
+
+	out := bytes.NewBufferString("")
+	if err := runCmdExport(out, "-f", dst); err == nil {
+		t.Fatal("expected error writing plaintext secrets without confirmation")
+	}
+
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Fatalf("expected no file written, got: %v", err)
+	}
+}
+
+func runCmdExport(output io.Writer, extra ...string) error {
+	op := newCmdExport()
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	op.SetFlags(fs)
+
+	args := append([]string{"-s", testStore}, extra...)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return op.Execute(fs)
+}
@@ -0,0 +1,51 @@
+package flags
+
+import (
+	"path"
+	"strings"
+
+	"github.com/lucasepe/strcase"
+)
+
+// KeyPattern is a secret key or, when it holds any of the '*', '?'
+// or '[' wildcards, a glob pattern matching the keys.
+type KeyPattern struct {
+	content []byte
+	pattern bool
+}
+
+func (f *KeyPattern) String() string {
+	return string(f.content)
+}
+
+func (f *KeyPattern) Set(v string) (err error) {
+	if !strings.ContainsAny(v, "*?[") {
+		f.content, f.pattern = []byte(strcase.Snake(v)), false
+		return nil
+	}
+
+	if _, err := path.Match(v, ""); err != nil {
+		return err
+	}
+	f.content, f.pattern = []byte(v), true
+	return nil
+}
+
+func (f *KeyPattern) Bytes() []byte {
+	return f.content
+}
+
+// IsPattern reports whether the value is a glob pattern.
+func (f *KeyPattern) IsPattern() bool {
+	return f.pattern
+}
+
+// Match reports whether the key matches the pattern (or is the key).
+func (f *KeyPattern) Match(key string) bool {
+	if !f.pattern {
+		return key == string(f.content)
+	}
+
+	ok, _ := path.Match(string(f.content), key)
+	return ok
+}
@@ -0,0 +1,63 @@
+package flags
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestKeyPatternFlag(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    string
+		pattern bool
+		match   []string
+		skip    []string
+	}{
+		{arg: "user name", want: "user_name", match: []string{"user_name"}, skip: []string{"user"}},
+		{arg: "aws_*", want: "aws_*", pattern: true, match: []string{"aws_key", "aws_"}, skip: []string{"aws"}},
+		{arg: "*token?", want: "*token?", pattern: true, match: []string{"api_token1"}, skip: []string{"token"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.arg, func(t *testing.T) {
+			fv := KeyPattern{}
+
+			var fs flag.FlagSet
+			fs.Var(&fv, "k", "")
+
+			if err := fs.Parse([]string{"-k", tc.arg}); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := fv.String(); got != tc.want {
+				t.Fatalf("expected: %v, got: %v", tc.want, got)
+			}
+			if got := fv.IsPattern(); got != tc.pattern {
+				t.Fatalf("expected pattern: %v, got: %v", tc.pattern, got)
+			}
+			for _, el := range tc.match {
+				if !fv.Match(el) {
+					t.Fatalf("expected %s to match %s", tc.arg, el)
+				}
+			}
+			for _, el := range tc.skip {
+				if fv.Match(el) {
+					t.Fatalf("expected %s not to match %s", tc.arg, el)
+				}
+			}
+		})
+	}
+}
+
+func TestKeyPatternFlagInvalid(t *testing.T) {
+	fv := KeyPattern{}
+
+	var fs flag.FlagSet
+	fs.Var(&fv, "k", "")
+	fs.SetOutput(io.Discard)
+
+	if err := fs.Parse([]string{"-k", "[aws"}); err == nil {
+		t.Fatal("expected error on invalid pattern")
+	}
+}
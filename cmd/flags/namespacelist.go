@@ -0,0 +1,25 @@
+package flags
+
+import (
+	"fmt"
+
+	"github.com/lucasepe/strcase"
+)
+
+// NamespaceList is a repeatable namespace flag.
+type NamespaceList struct {
+	names []string
+}
+
+func (f *NamespaceList) String() string {
+	return fmt.Sprint(f.names)
+}
+
+func (f *NamespaceList) Set(v string) error {
+	f.names = append(f.names, strcase.Kebab(v))
+	return nil
+}
+
+func (f *NamespaceList) Values() []string {
+	return f.names
+}
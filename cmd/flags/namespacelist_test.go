@@ -0,0 +1,24 @@
+package flags
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestNamespaceListFlag(t *testing.T) {
+	fv := NamespaceList{}
+
+	var fs flag.FlagSet
+	fs.Var(&fv, "namespace", "")
+
+	err := fs.Parse([]string{"-namespace", "Google.com", "-namespace", "AWS Prod"})
+	if err != nil {
+		t.Fail()
+	}
+
+	want := "[google-com aws-prod]"
+	got := fv.String()
+	if got != want {
+		t.Fatalf("expected: %v, got: %v", want, got)
+	}
+}
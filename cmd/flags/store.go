@@ -1,22 +1,39 @@
 package flags
 
 import (
+	"bufio"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/lucasepe/locker/internal/agent"
 	"github.com/lucasepe/locker/internal/kv"
 	"github.com/lucasepe/locker/internal/kv/bbolt"
 	"github.com/lucasepe/strcase"
 )
 
+// openTimeout is how long to wait for a store
+// in use by another process (i.e. a locker shell).
+var openTimeout = 5 * time.Second
+
 const (
 	defaultStoreName = "locker"
+	// registryFileName is the file, in BaseDir, listing
+	// the stores opened from outside BaseDir.
+	registryFileName = "stores.txt"
 )
 
 type Store struct {
 	BaseDir      string
 	MasterSecret string
+	// Default is the store used when not set ('locker' if empty).
+	Default string
+	// Aliases are the paths of the stores outside BaseDir, by name.
+	Aliases map[string]string
 
+	name string
 	path string
 	ref  kv.Store
 }
@@ -25,30 +42,151 @@ func (f *Store) String() string {
 	return f.path
 }
 
+// Set sets the store by alias, by path (i.e. './team.db', '/mnt/usb/locker.db')
+// or by name (the file '<kebab-name>.db' in BaseDir).
 func (f *Store) Set(v string) (err error) {
+	if path, ok := f.Aliases[v]; ok {
+		f.name, f.path = v, path
+		return nil
+	}
+
+	if isPath(v) {
+		path, err := filepath.Abs(v)
+		if err != nil {
+			return err
+		}
+		f.name, f.path = path, path
+		return nil
+	}
+
 	name := v[:len(v)-len(filepath.Ext(v))]
 	name = strcase.Kebab(name)
+	f.name = name
 	f.path = filepath.Join(f.BaseDir, fmt.Sprintf("%s.db", name))
 
 	return nil
 }
 
+// Name returns the name (or the alias) of the store.
+func (f *Store) Name() string {
+	f.setDefault()
+	return f.name
+}
+
+// setDefault sets the default store, if not set.
+func (f *Store) setDefault() error {
+	if len(f.path) > 0 {
+		return nil
+	}
+
+	if len(f.Default) > 0 {
+		return f.Set(f.Default)
+	}
+
+	return f.Set(defaultStoreName)
+}
+
+// Exists reports whether the store file has already been created.
+func (f *Store) Exists() bool {
+	f.setDefault()
+
+	_, err := os.Stat(f.path)
+	return err == nil
+}
+
+// Connect opens the store; stores written by an older build
+// are upgraded to the current schema version.
+// The store is served by the agent when its socket env var is set.
 func (f *Store) Connect() (kv.Store, error) {
 	if f.ref != nil {
 		return f.ref, nil
 	}
 
-	if len(f.path) == 0 {
-		err := f.Set(defaultStoreName)
-		if err != nil {
-			return nil, err
-		}
+	if err := f.setDefault(); err != nil {
+		return nil, err
+	}
+
+	sto, err := f.Open(f.path)
+	if err != nil {
+		return nil, err
 	}
 
-	opts := bbolt.Options{Path: f.path}
+	// listed by info, not required to work
+	f.register()
+
+	return sto, nil
+}
+
+// Open opens the store file at path with the master secret or, when
+// the secret is not set and the agent socket env var is, through the
+// agent (not to encrypt with the agent secret a store having its own);
+// it fails with kv.ErrStoreInUse if another process keeps it open.
+func (f *Store) Open(path string) (kv.Store, error) {
+	if sock := os.Getenv(agent.EnvSock); len(sock) > 0 && len(f.MasterSecret) == 0 {
+		return agent.Dial(sock, path)
+	}
+
+	opts := bbolt.Options{Path: path, Timeout: openTimeout}
 	if len(f.MasterSecret) > 0 {
 		opts.Codec = kv.NewCryptoCodec(f.MasterSecret)
 	}
 
 	return bbolt.NewStore(opts)
 }
+
+// Registered returns the paths of the stores opened from outside baseDir.
+func Registered(baseDir string) ([]string, error) {
+	fp, err := os.Open(filepath.Join(baseDir, registryFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+
+	var res []string
+	sc := bufio.NewScanner(fp)
+	for sc.Scan() {
+		if line := strings.TrimSpace(sc.Text()); len(line) > 0 {
+			res = append(res, line)
+		}
+	}
+
+	return res, sc.Err()
+}
+
+// register adds the store to the registry, if outside BaseDir.
+func (f *Store) register() error {
+	if filepath.Dir(f.path) == filepath.Clean(f.BaseDir) {
+		return nil
+	}
+
+	all, err := Registered(f.BaseDir)
+	if err != nil {
+		return err
+	}
+	for _, el := range all {
+		if el == f.path {
+			return nil
+		}
+	}
+
+	fp, err := os.OpenFile(filepath.Join(f.BaseDir, registryFileName),
+		os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(fp, f.path); err != nil {
+		fp.Close()
+		return err
+	}
+
+	return fp.Close()
+}
+
+// isPath reports whether the value is a path rather than a store name.
+func isPath(v string) bool {
+	return filepath.IsAbs(v) || strings.ContainsAny(v, "/"+string(filepath.Separator))
+}
@@ -1,10 +1,14 @@
 package flags
 
 import (
+	"errors"
 	"flag"
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/lucasepe/locker/internal/kv"
 	"github.com/lucasepe/xdg"
 )
 
@@ -27,3 +31,144 @@ func TestStore(t *testing.T) {
 		t.Fatalf("expected: %v, got: %v", want, got)
 	}
 }
+
+func TestStoreExists(t *testing.T) {
+	fv := Store{
+		BaseDir:      t.TempDir(),
+		MasterSecret: "abbracadabbra",
+	}
+
+	if err := fv.Set("test"); err != nil {
+		t.Fatal(err)
+	}
+	if fv.Exists() {
+		t.Fatalf("store %s should not exist", fv.String())
+	}
+
+	sto, err := fv.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sto.Close()
+
+	if !fv.Exists() {
+		t.Fatalf("store %s should exist", fv.String())
+	}
+}
+
+func TestStoreInUse(t *testing.T) {
+	defer func(d time.Duration) { openTimeout = d }(openTimeout)
+	openTimeout = 10 * time.Millisecond
+
+	fv := Store{
+		BaseDir:      t.TempDir(),
+		MasterSecret: "abbracadabbra",
+	}
+
+	sto, err := fv.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sto.Close()
+
+	// i.e. by a locker shell
+	if _, err := fv.Open(fv.String()); !errors.Is(err, kv.ErrStoreInUse) {
+		t.Fatalf("expected: %v, got: %v", kv.ErrStoreInUse, err)
+	}
+}
+
+func TestStoreDefaultAndAliases(t *testing.T) {
+	dir := t.TempDir()
+	usb := filepath.Join(t.TempDir(), "work.db")
+
+	fv := Store{
+		BaseDir: dir,
+		Default: "usb",
+		Aliases: map[string]string{"usb": usb},
+	}
+
+	if got := fv.Name(); got != "usb" {
+		t.Fatalf("expected: usb, got: %s", got)
+	}
+	if got := fv.String(); got != usb {
+		t.Fatalf("expected: %s, got: %s", usb, got)
+	}
+
+	if err := fv.Set("Personal Stuff"); err != nil {
+		t.Fatal(err)
+	}
+	if got := fv.Name(); got != "personal-stuff" {
+		t.Fatalf("expected: personal-stuff, got: %s", got)
+	}
+	if want, got := filepath.Join(dir, "personal-stuff.db"), fv.String(); got != want {
+		t.Fatalf("expected: %s, got: %s", want, got)
+	}
+}
+
+func TestStorePath(t *testing.T) {
+	// the temporary directory may be a symbolic link
+	base, ext := t.TempDir(), evalSymlinks(t.TempDir())
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(ext); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		val  string
+		want string
+	}{
+		{filepath.Join(ext, "team.db"), filepath.Join(ext, "team.db")},
+		{"./team.db", filepath.Join(ext, "team.db")},
+		{"sub/team.db", filepath.Join(ext, "sub", "team.db")},
+		{"team.db", filepath.Join(base, "team.db")},
+	}
+
+	for _, tc := range tests {
+		fv := Store{BaseDir: base}
+		if err := fv.Set(tc.val); err != nil {
+			t.Fatal(err)
+		}
+		if got := fv.String(); got != tc.want {
+			t.Fatalf("%s: expected: %s, got: %s", tc.val, tc.want, got)
+		}
+	}
+
+	// the stores outside BaseDir are registered once
+	for i := 0; i < 2; i++ {
+		fv := Store{BaseDir: base, MasterSecret: "abbracadabbra"}
+		fv.Set(filepath.Join(ext, "team.db"))
+		sto, err := fv.Connect()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sto.Close()
+	}
+
+	fv := Store{BaseDir: base, MasterSecret: "abbracadabbra"}
+	sto, err := fv.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sto.Close()
+
+	got, err := Registered(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(ext, "team.db")}; len(got) != 1 || got[0] != want[0] {
+		t.Fatalf("expected: %v, got: %v", want, got)
+	}
+}
+
+func evalSymlinks(path string) string {
+	res, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return path
+	}
+	return res
+}
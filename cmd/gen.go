@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/lucasepe/locker/cmd/flags"
+	"github.com/lucasepe/locker/internal/passgen"
+)
+
+func newCmdGen() *cmdGen {
+	return &cmdGen{
+		namespace: flags.Namespace{},
+		key:       flags.Key{},
+		storeRef:  newStoreFlag(),
+		policy:    passgen.DefaultPolicy,
+	}
+}
+
+type cmdGen struct {
+	namespace  flags.Namespace
+	key        flags.Key
+	storeRef   flags.Store
+	policy     passgen.Policy
+	noLower    bool
+	noUpper    bool
+	noDigits   bool
+	noSymbols  bool
+	passphrase bool
+	words      int
+	separator  string
+	store      bool
+	output     string
+}
+
+// genResult is the structured output of gen, when not storing the secret.
+type genResult struct {
+	Secret string `json:"secret" yaml:"secret"`
+}
+
+func (*cmdGen) Name() string { return "gen" }
+func (*cmdGen) Synopsis() string {
+	return "Generate a random password or passphrase."
+}
+
+func (*cmdGen) Usage() string {
+	return strings.ReplaceAll(`{NAME} gen [flags]
+  
+   Generate a 32 characters password without ambiguous characters:
+     {NAME} gen -length 32 -exclude-ambiguous
+
+   Generate a password with only letters and digits:
+     {NAME} gen -no-symbols
+
+   Generate a passphrase of 7 words:
+     {NAME} gen -passphrase -words 7
+
+   Generate a password and store it with key 'password' into the 'instagram' namespace:
+     {NAME} gen -store -n instagram -k password`, "{NAME}", appLowerName)
+}
+
+func (c *cmdGen) SetFlags(fs *flag.FlagSet) {
+	fs.IntVar(&c.policy.Length, "length", c.policy.Length, "Password length.")
+	fs.BoolVar(&c.noLower, "no-lower", false, "Do not use lowercase letters.")
+	fs.BoolVar(&c.noUpper, "no-upper", false, "Do not use uppercase letters.")
+	fs.BoolVar(&c.noDigits, "no-digits", false, "Do not use digits.")
+	fs.BoolVar(&c.noSymbols, "no-symbols", false, "Do not use symbols.")
+	fs.StringVar(&c.policy.Symbols, "symbols", c.policy.Symbols, "Allowed symbols.")
+	fs.IntVar(&c.policy.MinSymbols, "min-symbols", c.policy.MinSymbols, "Minimum number of symbols.")
+	fs.BoolVar(&c.policy.ExcludeAmbiguous, "exclude-ambiguous", false, "Exclude ambiguous characters (i.e. 'l', '1', 'O', '0').")
+	fs.BoolVar(&c.passphrase, "passphrase", false, "Generate a passphrase instead of a password.")
+	fs.IntVar(&c.words, "words", 6, "Number of words in the passphrase.")
+	fs.StringVar(&c.separator, "sep", "-", "Passphrase words separator.")
+	fs.BoolVar(&c.store, "store", false, "Store the result instead of printing it.")
+	fs.Var(&c.namespace, "n", "Namespace (with -store).")
+	fs.Var(&c.storeRef, "s", "Store name (with -store).")
+	fs.Var(&c.key, "k", "Secret key (with -store).")
+}
+
+func (c *cmdGen) Execute(fs *flag.FlagSet) error {
+	if err := c.complete(fs); err != nil {
+		return err
+	}
+
+	var val string
+	var err error
+	if c.passphrase {
+		val, err = passgen.Passphrase(c.words, c.separator)
+	} else {
+		val, err = passgen.Password(c.policy)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !c.store {
+		if structured(c.output) {
+			return encodeOutput(fs.Output(), c.output, genResult{Secret: val})
+		}
+		fmt.Fprintln(fs.Output(), val)
+		return nil
+	}
+
+	sto, err := c.storeRef.Connect()
+	if err != nil {
+		return err
+	}
+	defer sto.Close()
+
+	if err := sto.PutOne(c.namespace.String(), c.key.String(), val); err != nil {
+		return err
+	}
+
+	if err := recordAccess(sto, "put", c.namespace.String(), c.key.String()); err != nil {
+		return err
+	}
+
+	if structured(c.output) {
+		return encodeOutput(fs.Output(), c.output, statusResult{
+			Status:    "stored",
+			Store:     filepath.Base(c.storeRef.String()),
+			Namespace: c.namespace.String(),
+			Key:       c.key.String(),
+		})
+	}
+
+	fmt.Fprintf(fs.Output(), "secret successfully generated and stored (key:%s, namespace: %s, store: %s)\n",
+		c.key.String(), c.namespace.String(), filepath.Base(c.storeRef.String()))
+
+	return nil
+}
+
+func (c *cmdGen) complete(fs *flag.FlagSet) error {
+	c.output = globalOutput()
+
+	c.policy.Lower = !c.noLower
+	c.policy.Upper = !c.noUpper
+	c.policy.Digits = !c.noDigits
+	if c.noSymbols {
+		c.policy.Symbols = ""
+	}
+
+	if !c.store {
+		return nil
+	}
+
+	if len(c.namespace.Bytes()) == 0 {
+		return fmt.Errorf("missing namespace")
+	}
+
+	if len(c.key.Bytes()) == 0 {
+		return fmt.Errorf("missing key")
+	}
+
+	pwd, err := getMasterSecret(&c.storeRef)
+	if err != nil {
+		return err
+	}
+	c.storeRef.MasterSecret = pwd
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCmdGen(t *testing.T) {
+	out := bytes.NewBufferString("")
+	if err := runCmdGen(out, "-length", "32", "-no-symbols"); err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.TrimSpace(out.String())
+	if len(got) != 32 {
+		t.Fatalf("expected 32 characters, got: %s", got)
+	}
+	if strings.Trim(got, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789") != "" {
+		t.Fatalf("expected only letters and digits, got: %s", got)
+	}
+}
+
+func TestCmdGenStore(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	err := runCmdGen(out, "-passphrase", "-words", "4", "-sep", " ",
+		"-store", "-s", testStore, "-n", testNamespace, "-k", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.TrimSpace(out.String())
+	want := "secret successfully generated and stored"
+	if !strings.HasPrefix(got, want) {
+		t.Fatalf("expected prefix: %v, got: %v", want, got)
+	}
+
+	out.Reset()
+	if err := runCmdGet(out, "password"); err != nil {
+		t.Fatal(err)
+	}
+
+	if words := strings.Fields(out.String()); len(words) != 4 {
+		t.Fatalf("expected a 4 words passphrase, got: %s", out.String())
+	}
+}
+
+func runCmdGen(output io.Writer, args ...string) error {
+	op := newCmdGen()
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	op.SetFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return op.Execute(fs)
+}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"io"
 	"runtime"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/lucasepe/locker/cmd/flags"
-	"github.com/lucasepe/locker/internal/clipboard"
+	"github.com/lucasepe/locker/internal/envvar"
 	"github.com/lucasepe/locker/internal/kv"
 )
 
@@ -18,22 +20,42 @@ const (
 )
 
 func newCmdGet() *cmdGet {
-	return &cmdGet{
+	c := &cmdGet{
 		namespace: flags.Namespace{},
 		keys:      flags.StringList{},
-		storeRef: flags.Store{
-			BaseDir: AppDir(),
-		},
-		output: flags.Enum{Choices: []string{fmtEnv, fmtTxt}},
+		storeRef:  newStoreFlag(),
+		output: flags.Enum{Choices: []string{
+			fmtTxt, fmtEnv, envvar.Dotenv, envvar.Sh, envvar.Fish, envvar.Docker,
+			fmtK8sSecret, fmtCompose, fmtSystemd, fmtJSON, fmtYAML,
+		}},
 		exportFuncMap: map[string]exportFunc{
-			fmtEnv: func(w io.Writer, k, v string) {
-				fmt.Fprintf(w, "%s=%s", strings.ToUpper(k), v)
-			},
-			fmtTxt: func(w io.Writer, k, v string) {
-				fmt.Fprintf(w, "%s: %s", k, v)
+			fmtTxt: func(w io.Writer, k, v string) error {
+				_, err := fmt.Fprintf(w, "%s: %s", k, v)
+				return err
 			},
+			// the env format is a dotenv file
+			fmtEnv:        exportEnv(envvar.Dotenv),
+			envvar.Dotenv: exportEnv(envvar.Dotenv),
+			envvar.Sh:     exportEnv(envvar.Sh),
+			envvar.Fish:   exportEnv(envvar.Fish),
+			envvar.Docker: exportEnv(envvar.Docker),
+			fmtK8sSecret:  exportK8sSecret,
+		},
+		exportHeaderMap: map[string]func(w io.Writer) error{
+			fmtCompose: composeHeader,
+		},
+		exportCheckMap: map[string]func(key string) error{
+			fmtK8sSecret: checkK8sKey,
+			fmtCompose:   checkFileName,
+			fmtSystemd:   checkCredentialName,
 		},
 	}
+
+	c.exportFuncMap[fmtCompose] = c.exportCompose
+	c.exportFuncMap[fmtSystemd] = c.exportSystemd
+	c.exportHeaderMap[fmtK8sSecret] = c.k8sSecretHeader
+
+	return c
 }
 
 type cmdGet struct {
@@ -41,7 +63,19 @@ type cmdGet struct {
 	keys          flags.StringList
 	storeRef      flags.Store
 	output        flags.Enum
+	prefix        string
+	strict        bool
+	name          string
+	dir           string
+	clip          bool
+	clipTimeout   time.Duration
 	exportFuncMap map[string]exportFunc
+	// exportHeaderMap holds the functions writing what precedes
+	// the exported secrets, for the formats that need it.
+	exportHeaderMap map[string]func(w io.Writer) error
+	// exportCheckMap holds the functions checking the keys, all of
+	// them before anything is written, for the formats that need it.
+	exportCheckMap map[string]func(key string) error
 }
 
 func (*cmdGet) Name() string { return "get" }
@@ -55,15 +89,43 @@ func (*cmdGet) Usage() string {
    Get the secret with key 'user' from the 'google' namespace:
      {NAME} get -n Google -k user
 
+   Copy the secret with key 'password' to the clipboard, cleared after 10 seconds:
+     {NAME} get -n google -k password -clip -clip-timeout 10s
+
    Get all secrets from the 'google' namespace:
-     {NAME} get -n google`, "{NAME}", appLowerName)
+     {NAME} get -n google
+
+   Get all secrets from the 'google' namespace as JSON:
+     {NAME} get -n google -o json
+
+   Load the secrets of the 'db' namespace, as DB_USER, DB_PASSWORD..., in the shell:
+     eval "$({NAME} get -n db -o sh -p DB_)"
+
+   Write a docker --env-file (also: dotenv, fish):
+     {NAME} get -n db -o docker > db.env
+
+   Create a Kubernetes Secret named 'app-creds' with the secrets of the 'app' namespace:
+     {NAME} get -n app -o k8s-secret -name app-creds | kubectl apply -f -
+
+   Write the secrets to files in './secrets' and print the compose 'secrets' element:
+     {NAME} get -n app -o compose -dir ./secrets
+
+   Write the secrets to files in a directory and print the systemd LoadCredential= settings:
+     {NAME} get -n app -o systemd -dir /etc/credstore/app`, "{NAME}", appLowerName)
 }
 
 func (c *cmdGet) SetFlags(fs *flag.FlagSet) {
 	fs.Var(&c.namespace, "n", "Namespace.")
-	fs.Var(&c.storeRef, "s", "Store name.")
+	fs.Var(&c.storeRef, "s", "Store name, alias or path.")
 	fs.Var(&c.keys, "k", "Secret key.")
 	fs.Var(&c.output, "o", fmt.Sprintf("Output format, one of: %s", strings.Join(c.output.Choices, ",")))
+	fs.StringVar(&c.prefix, "p", "", "Variable names prefix (env formats only).")
+	fs.BoolVar(&c.strict, "strict", false,
+		"Fail on keys that are not valid variable names, instead of replacing the invalid characters (env formats only).")
+	fs.StringVar(&c.name, "name", "", "Secret name (k8s-secret format only, the namespace by default).")
+	fs.StringVar(&c.dir, "dir", "", "Directory of the secret files (compose and systemd formats only).")
+	fs.BoolVar(&c.clip, "clip", false, "Copy the secret to the clipboard instead of printing it.")
+	fs.DurationVar(&c.clipTimeout, "clip-timeout", clipboardTimeout(), "Clear the clipboard after this time (0 to disable).")
 }
 
 func (c *cmdGet) Execute(fs *flag.FlagSet) error {
@@ -91,11 +153,30 @@ func (c *cmdGet) extractAll(sto kv.Store, fs *flag.FlagSet) error {
 		return err
 	}
 
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if err := recordAccess(sto, "get", c.namespace.String(), keys...); err != nil {
+		return err
+	}
+
 	of := c.output.String()
-	i := 0
-	for k, v := range all {
-		c.exportFuncMap[of](fs.Output(), k, v)
-		if i < len(all)-1 {
+	if structured(of) {
+		return encodeOutput(fs.Output(), of, getResult{Namespace: c.namespace.String(), Secrets: all})
+	}
+
+	if of != fmtTxt {
+		return c.export(fs.Output(), keys, all)
+	}
+
+	for i, k := range keys {
+		if err := c.exportFuncMap[of](fs.Output(), k, all[k]); err != nil {
+			return err
+		}
+		if i < len(keys)-1 {
 			fmt.Fprintln(fs.Output())
 		}
 	}
@@ -103,6 +184,67 @@ func (c *cmdGet) extractAll(sto kv.Store, fs *flag.FlagSet) error {
 	return nil
 }
 
+// export writes a line, in the output format, for each key
+// (preceded by the header of the format, if any).
+func (c *cmdGet) export(w io.Writer, keys []string, all map[string]string) error {
+	of := c.output.Value
+
+	names := make(map[string]string, len(keys))
+	for _, k := range keys {
+		names[k] = k
+	}
+	if of == fmtEnv || contains(envvar.Formats, of) {
+		var err error
+		if names, err = c.envNames(keys); err != nil {
+			return err
+		}
+	}
+
+	if check, ok := c.exportCheckMap[of]; ok {
+		for _, k := range keys {
+			if err := check(names[k]); err != nil {
+				return err
+			}
+		}
+	}
+
+	// the lines are written only if all the values can be exported
+	var sb strings.Builder
+	if header, ok := c.exportHeaderMap[of]; ok {
+		if err := header(&sb); err != nil {
+			return err
+		}
+	}
+	for _, k := range keys {
+		if err := c.exportFuncMap[of](&sb, names[k], all[k]); err != nil {
+			return err
+		}
+		sb.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// envNames returns the variable names of the keys.
+func (c *cmdGet) envNames(keys []string) (map[string]string, error) {
+	res := make(map[string]string, len(keys))
+	seen := make(map[string]string, len(keys))
+	for _, k := range keys {
+		name := envvar.Name(c.prefix, k)
+		if c.strict && !envvar.Valid(strings.ToUpper(c.prefix+k)) {
+			return nil, fmt.Errorf("key %s: %s is not a valid variable name", k, strings.ToUpper(c.prefix+k))
+		}
+		if other, ok := seen[name]; ok {
+			return nil, fmt.Errorf("keys %s and %s have the same variable name: %s", other, k, name)
+		}
+		seen[name] = k
+		res[k] = name
+	}
+
+	return res, nil
+}
+
 func (c *cmdGet) extractOne(sto kv.Store, fs *flag.FlagSet) error {
 	key := c.keys.Values()[0]
 	val, err := sto.GetOne(c.namespace.String(), key)
@@ -110,37 +252,71 @@ func (c *cmdGet) extractOne(sto kv.Store, fs *flag.FlagSet) error {
 		return err
 	}
 
+	if err := recordAccess(sto, "get", c.namespace.String(), key); err != nil {
+		return err
+	}
+
+	if structured(c.output.Value) {
+		return encodeOutput(fs.Output(), c.output.Value,
+			getResult{Namespace: c.namespace.String(), Secrets: map[string]string{key: val}})
+	}
+
 	if c.output.Value != fmtTxt {
-		c.exportFuncMap[c.output.Value](fs.Output(), key, val)
-		return nil
+		return c.export(fs.Output(), []string{key}, map[string]string{key: val})
 	}
 
-	if runtime.GOOS != "darwin" {
+	// on macOS the secret is always copied, if possible
+	if !c.clip && runtime.GOOS != "darwin" {
 		fmt.Fprint(fs.Output(), val)
 		return nil
 	}
 
-	if err := clipboard.Write(val); err == nil {
-		fmt.Fprintf(fs.Output(),
-			"secret has been copied to the clipboard (namespace: %s, key: %s)\n",
-			c.namespace.String(), key)
-	} else {
+	clearIn, err := copyToClipboard(val, c.clipTimeout)
+	if err != nil {
+		if c.clip {
+			return err
+		}
 		fmt.Fprint(fs.Output(), val)
+		return nil
+	}
+
+	fmt.Fprintf(fs.Output(),
+		"secret has been copied to the clipboard (namespace: %s, key: %s)\n",
+		c.namespace.String(), key)
+	if clearIn > 0 {
+		fmt.Fprintf(fs.Output(), "the clipboard will be cleared in %s\n", clearIn)
 	}
 
 	return nil
 }
 
 func (c *cmdGet) complete(fs *flag.FlagSet) error {
+	// the default formats are not for the clipboard
+	if c.clip && c.output.Value == "" {
+		c.output.Set(fmtTxt)
+	}
+	resolveOutput(&c.output, fmtTxt)
+
 	if len(c.namespace.Bytes()) == 0 {
 		return fmt.Errorf("missing namespace")
 	}
 
-	if c.output.Value == "" {
-		c.output.Set(fmtTxt)
+	if c.clip && (len(c.keys.Values()) != 1 || c.output.Value != fmtTxt) {
+		return fmt.Errorf("only one secret in txt format can be copied to the clipboard")
 	}
 
-	pwd, err := getMasterSecret()
+	switch c.output.Value {
+	case fmtK8sSecret:
+		if !k8sNameRe.MatchString(c.secretName()) {
+			return fmt.Errorf("not a valid Kubernetes Secret name: %s (use -name)", c.secretName())
+		}
+	case fmtCompose, fmtSystemd:
+		if len(c.dir) == 0 {
+			return fmt.Errorf("missing directory of the secret files (use -dir)")
+		}
+	}
+
+	pwd, err := getMasterSecret(&c.storeRef)
 	if err != nil {
 		return err
 	}
@@ -149,4 +325,23 @@ func (c *cmdGet) complete(fs *flag.FlagSet) error {
 	return nil
 }
 
-type exportFunc func(w io.Writer, key, val string)
+type exportFunc func(w io.Writer, key, val string) error
+
+// exportEnv returns the exportFunc writing the assignment
+// of the value to the variable (the key) in the format.
+func exportEnv(format string) exportFunc {
+	return func(w io.Writer, name, val string) error {
+		line, err := envvar.Assign(format, name, val)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, line)
+		return err
+	}
+}
+
+// getResult is the structured output of get.
+type getResult struct {
+	Namespace string            `json:"namespace" yaml:"namespace"`
+	Secrets   map[string]string `json:"secrets" yaml:"secrets"`
+}
@@ -3,10 +3,16 @@ package cmd
 import (
 	"bytes"
 	"flag"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+
+	"github.com/lucasepe/locker/internal/config"
+	"github.com/lucasepe/locker/internal/envvar"
 )
 
 func TestCmdGetOne(t *testing.T) {
@@ -84,3 +90,157 @@ func runCmdGet(output io.Writer, key string) error {
 
 	return op.Execute(fs)
 }
+
+func TestCmdGetClip(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the fake clipboard tool is a shell script for X11")
+	}
+
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	// a fake xclip saving the content to a file
+	dir := t.TempDir()
+	data := filepath.Join(dir, "clipboard")
+	script := fmt.Sprintf("#!/bin/sh\ncat > %s\n", data)
+	if err := os.WriteFile(filepath.Join(dir, "xclip"), []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("DISPLAY", ":0")
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the default output formats are ignored
+	defer func() { cfg, outputFormat.Value = &config.Config{}, "" }()
+	cfg = &config.Config{Output: envvar.Sh}
+	outputFormat.Set(fmtJSON)
+
+	out.Reset()
+	op := newCmdGet()
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(out)
+	op.SetFlags(fs)
+
+	args := []string{"-n", testNamespace, "-s", testStore, "-k", "password", "-clip", "-clip-timeout", "0"}
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.Execute(fs); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "secret has been copied to the clipboard (namespace: stuffs, key: password)\n"
+	if got := out.String(); got != want {
+		t.Fatalf("expected: %q, got: %q", want, got)
+	}
+
+	got, err := os.ReadFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "magick" {
+		t.Fatalf("expected: magick, got: %s", got)
+	}
+}
+
+func TestCmdGetJSON(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "user name", "pinco.pallo@gmail.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	op := newCmdGet()
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(out)
+	op.SetFlags(fs)
+
+	if err := fs.Parse([]string{"-n", testNamespace, "-s", testStore, "-o", "json"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.Execute(fs); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  "namespace": "stuffs",
+  "secrets": {
+    "password": "magick",
+    "user_name": "pinco.pallo@gmail.com"
+  }
+}
+`
+	if got := out.String(); got != want {
+		t.Fatalf("expected: %s, got: %s", want, got)
+	}
+}
+
+func TestCmdGetEnvFormats(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "user name", "pinco pallo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCmdPut(out, "password", `it's "magick"`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-o", "env"}, "PASSWORD=\"it's \\\"magick\\\"\"\nUSER_NAME='pinco pallo'\n"},
+		{[]string{"-o", "sh", "-p", "DB_"}, "export DB_PASSWORD='it'\\''s \"magick\"'\nexport DB_USER_NAME='pinco pallo'\n"},
+		{[]string{"-o", "fish", "-k", "password"}, "set -gx PASSWORD 'it\\'s \"magick\"'\n"},
+		{[]string{"-o", "docker", "-p", "db."}, "DB_PASSWORD=it's \"magick\"\nDB_USER_NAME=pinco pallo\n"},
+	}
+
+	for _, tc := range tests {
+		out.Reset()
+		op := newCmdGet()
+		fs := flag.NewFlagSet("", flag.ContinueOnError)
+		fs.SetOutput(out)
+		op.SetFlags(fs)
+
+		if err := fs.Parse(append([]string{"-n", testNamespace, "-s", testStore}, tc.args...)); err != nil {
+			t.Fatal(err)
+		}
+		if err := op.Execute(fs); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := out.String(); got != tc.want {
+			t.Fatalf("%v: expected: %q, got: %q", tc.args, tc.want, got)
+		}
+	}
+
+	// the prefix is not a valid name
+	out.Reset()
+	op := newCmdGet()
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(out)
+	op.SetFlags(fs)
+
+	if err := fs.Parse([]string{"-n", testNamespace, "-s", testStore, "-o", "sh", "-p", "db.", "-strict"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.Execute(fs); err == nil || out.Len() > 0 {
+		t.Fatalf("expected error and no output, got: %v (%q)", err, out.String())
+	}
+}
@@ -1,30 +1,88 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"encoding/pem"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/lucasepe/locker/cmd/flags"
+	"github.com/lucasepe/locker/internal/importer"
+	"github.com/lucasepe/locker/internal/secrets"
 	"github.com/lucasepe/strcase"
 
 	"gopkg.in/yaml.v3"
 )
 
+const (
+	fmtAuto   = "auto"
+	fmtLocker = "locker"
+)
+
 func newCmdImport() *cmdImport {
-	return &cmdImport{
-		file: flags.FileFlag{},
-		storeRef: flags.Store{
-			BaseDir: AppDir(),
-		},
+	c := &cmdImport{
+		file:      flags.FileFlag{},
+		namespace: flags.Namespace{},
+		storeRef:  newStoreFlag(),
+		// auto-detection tries the formats in this order.
+		format: flags.Enum{Choices: []string{
+			fmtAuto, fmtLocker, "bitwarden", "keepass-xml", "keepass-csv",
+			"1password-csv", "k8s-secret", "dotenv", "pass",
+		}},
+	}
+
+	c.importers = map[string]importer.Importer{
+		fmtLocker:       lockerFormat{storeRef: &c.storeRef},
+		"bitwarden":     importer.Bitwarden{},
+		"keepass-xml":   importer.KeePassXML{},
+		"keepass-csv":   importer.KeePassCSV,
+		"1password-csv": importer.OnePasswordCSV,
+		"k8s-secret":    importer.KubeSecret{},
+		"dotenv":        importer.Dotenv{},
+		"pass":          importer.Pass{},
 	}
+
+	return c
 }
 
 type cmdImport struct {
-	file     flags.FileFlag
-	storeRef flags.Store
+	file      flags.FileFlag
+	namespace flags.Namespace
+	storeRef  flags.Store
+	format    flags.Enum
+	importers map[string]importer.Importer
+	output    string
+}
+
+// importResult is the structured output of import.
+type importResult struct {
+	Status     string   `json:"status" yaml:"status"`
+	Store      string   `json:"store" yaml:"store"`
+	Namespaces []string `json:"namespaces" yaml:"namespaces"`
+	Secrets    int      `json:"secrets" yaml:"secrets"`
+	// Renamed lists the duplicate secrets, saved under another key.
+	Renamed []importRenamed `json:"renamed,omitempty" yaml:"renamed,omitempty"`
+}
+
+type importRenamed struct {
+	Namespace string `json:"namespace" yaml:"namespace"`
+	Key       string `json:"key" yaml:"key"`
+	SavedAs   string `json:"saved_as" yaml:"saved_as"`
+}
+
+// uniqueKey returns the first key, among key_2, key_3 and so on,
+// not in keys.
+func uniqueKey(keys []string, key string) string {
+	for i := 2; ; i++ {
+		if dup := fmt.Sprintf("%s_%d", key, i); !contains(keys, dup) {
+			return dup
+		}
+	}
 }
 
 func (*cmdImport) Name() string { return "import" }
@@ -33,12 +91,26 @@ func (*cmdImport) Synopsis() string {
 }
 
 func (*cmdImport) Usage() string {
-	return strings.ReplaceAll("{NAME} import [flags]", "{NAME}", appLowerName)
+	return strings.ReplaceAll(`{NAME} import [flags]
+  
+   Import the secrets exported by {NAME} (the format is detected automatically):
+     {NAME} import -f backup.pem
+
+   Import a Bitwarden JSON export:
+     {NAME} import -format bitwarden -f bitwarden_export.json
+
+   Import a dotenv file into the 'db' namespace:
+     {NAME} import -n db -f .env
+
+   Import a pass-style directory tree of plaintext files:
+     {NAME} import -format pass -f ~/password-store`, "{NAME}", appLowerName)
 }
 
 func (c *cmdImport) SetFlags(fs *flag.FlagSet) {
-	fs.Var(&c.storeRef, "s", "Store name.")
-	fs.Var(&c.file, "f", "File to import.")
+	fs.Var(&c.storeRef, "s", "Store name, alias or path.")
+	fs.Var(&c.file, "f", "File (or directory) to import.")
+	fs.Var(&c.namespace, "n", "Namespace for formats without namespaces (defaults to the file name).")
+	fs.Var(&c.format, "format", fmt.Sprintf("Input format, one of: %s", strings.Join(c.format.Choices, ",")))
 }
 
 func (c *cmdImport) Execute(fs *flag.FlagSet) error {
@@ -46,11 +118,15 @@ func (c *cmdImport) Execute(fs *flag.FlagSet) error {
 		return err
 	}
 
-	rdr, err := os.OpenFile(c.file.String(), os.O_RDONLY, 0)
+	imp, err := c.importer()
+	if err != nil {
+		return err
+	}
+
+	recs, err := imp.Import(c.file.String())
 	if err != nil {
 		return err
 	}
-	defer rdr.Close()
 
 	db, err := c.storeRef.Connect()
 	if err != nil {
@@ -58,39 +134,109 @@ func (c *cmdImport) Execute(fs *flag.FlagSet) error {
 	}
 	defer db.Close()
 
-	count := 0
-	decoder := yaml.NewDecoder(rdr)
-	for {
-		var d SecretList
-		if err := decoder.Decode(&d); err != nil {
-			if err == io.EOF {
-				break
-			}
-			return fmt.Errorf("document decode failed: %w", err)
+	// group the keys by namespace, preserving their order
+	names := []string{}
+	keys := map[string][]string{}
+	var renamed []importRenamed
+	for _, el := range recs {
+		namespace := el.Namespace
+		if len(namespace) == 0 {
+			namespace = c.defaultNamespace()
 		}
+		namespace = strcase.Kebab(namespace)
+		key := strcase.Snake(el.Key)
 
-		for _, el := range d.Secrets {
-			err := db.PutOne(strcase.Kebab(d.Namespace), strcase.Snake(el.Key), el.Value)
-			if err != nil {
-				return fmt.Errorf("namespace: %s: %w", d.Namespace, err)
-			}
+		// the same secret found again (i.e. two entries with
+		// the same title) is kept under another key
+		if contains(keys[namespace], key) {
+			dup := uniqueKey(keys[namespace], key)
+			renamed = append(renamed, importRenamed{Namespace: namespace, Key: key, SavedAs: dup})
+			fmt.Fprintf(os.Stderr, "warn: duplicate secret (key: %s, namespace: %s) imported as %s\n",
+				key, namespace, dup)
+			key = dup
+		}
+
+		err := db.PutOne(namespace, key, el.Value)
+		if err != nil {
+			return fmt.Errorf("namespace: %s: %w", el.Namespace, err)
+		}
+
+		if _, ok := keys[namespace]; !ok {
+			names = append(names, namespace)
+		}
+		keys[namespace] = append(keys[namespace], key)
+	}
+
+	for _, namespace := range names {
+		if err := recordAccess(db, "import", namespace, keys[namespace]...); err != nil {
+			return err
 		}
-		count = count + 1
 	}
 
-	if count > 0 {
-		fmt.Fprintf(fs.Output(), "successfully imported %d documents\n", count)
+	if structured(c.output) {
+		return encodeOutput(fs.Output(), c.output, importResult{
+			Status:     "imported",
+			Store:      filepath.Base(c.storeRef.String()),
+			Namespaces: names,
+			Secrets:    len(recs),
+			Renamed:    renamed,
+		})
+	}
+
+	if len(names) > 0 {
+		fmt.Fprintf(fs.Output(), "successfully imported %d documents\n", len(names))
 	}
 
 	return nil
 }
 
+// importer returns the importer for the chosen format,
+// detecting it from the file content if not specified.
+func (c *cmdImport) importer() (importer.Importer, error) {
+	if c.format.Value != fmtAuto {
+		return c.importers[c.format.Value], nil
+	}
+
+	head, err := importer.Head(c.file.String())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range c.format.Choices {
+		imp, ok := c.importers[name]
+		if ok && imp.Detect(c.file.String(), head) {
+			return imp, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unable to detect the format of %s, use -format", c.file.String())
+}
+
+// defaultNamespace is used for records without a namespace.
+func (c *cmdImport) defaultNamespace() string {
+	if len(c.namespace.Bytes()) > 0 {
+		return c.namespace.String()
+	}
+
+	name := filepath.Base(c.file.String())
+	if trimmed := strings.TrimSuffix(name, filepath.Ext(name)); len(trimmed) > 0 {
+		name = trimmed
+	}
+	return name
+}
+
 func (c *cmdImport) complete(fs *flag.FlagSet) error {
+	c.output = globalOutput()
+
 	if len(c.file.String()) == 0 {
 		return fmt.Errorf("file to import not specified")
 	}
 
-	pwd, err := getMasterSecret()
+	if c.format.Value == "" {
+		c.format.Set(fmtAuto)
+	}
+
+	pwd, err := getMasterSecret(&c.storeRef)
 	if err != nil {
 		return err
 	}
@@ -99,13 +245,92 @@ func (c *cmdImport) complete(fs *flag.FlagSet) error {
 	return nil
 }
 
+// lockerFormat imports the files written by the export command.
+type lockerFormat struct {
+	storeRef *flags.Store
+}
+
+func (lockerFormat) Detect(_ string, head []byte) bool {
+	if bytes.Contains(head, []byte("-----BEGIN "+exportBlockType)) {
+		return true
+	}
+
+	return (bytes.Contains(head, []byte("namespace:")) && bytes.Contains(head, []byte("secrets:"))) ||
+		(bytes.Contains(head, []byte(`"namespace"`)) && bytes.Contains(head, []byte(`"secrets"`)))
+}
+
+func (f lockerFormat) Import(path string) ([]importer.Record, error) {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := decodeSecretLists(dat, f.storeRef.MasterSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []importer.Record
+	for _, d := range docs {
+		for _, el := range d.Secrets {
+			res = append(res, importer.Record{
+				Namespace: d.Namespace,
+				Key:       el.Key,
+				Value:     el.Value,
+			})
+		}
+	}
+
+	return res, nil
+}
+
+// decodeSecretLists parses the documents written by the export command:
+// a multi-document YAML stream or a JSON array, optionally encrypted
+// with the master secret.
+func decodeSecretLists(dat []byte, secret string) ([]SecretList, error) {
+	if blk, _ := pem.Decode(dat); blk != nil && blk.Type == exportBlockType {
+		// the agent never shares the master secret
+		if len(secret) == 0 {
+			return nil, fmt.Errorf("the master secret is needed to decrypt the file: %w", ErrUnsetMasterSecret)
+		}
+
+		var err error
+		dat, err = secrets.Decrypt([]byte(secret), blk.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decrypt the file: %w", err)
+		}
+	}
+
+	var res []SecretList
+	if bytes.HasPrefix(bytes.TrimSpace(dat), []byte("[")) {
+		if err := json.Unmarshal(dat, &res); err != nil {
+			return nil, fmt.Errorf("document decode failed: %w", err)
+		}
+		return res, nil
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(dat))
+	for {
+		var d SecretList
+		if err := decoder.Decode(&d); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("document decode failed: %w", err)
+		}
+		res = append(res, d)
+	}
+
+	return res, nil
+}
+
 // An Secret holds a label/value pair.
 type Secret struct {
-	Key   string `yaml:"key"`
-	Value string `yaml:"value"`
+	Key   string `yaml:"key" json:"key"`
+	Value string `yaml:"value" json:"value"`
 }
 
 type SecretList struct {
-	Namespace string   `yaml:"namespace"`
-	Secrets   []Secret `yaml:"secrets"`
+	Namespace string   `yaml:"namespace" json:"namespace"`
+	Secrets   []Secret `yaml:"secrets" json:"secrets"`
 }
@@ -2,11 +2,15 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"flag"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestCmdImport(t *testing.T) {
@@ -27,7 +31,87 @@ func TestCmdImport(t *testing.T) {
 	}
 }
 
+func TestCmdImportFormats(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	tests := map[string]string{
+		"../internal/importer/testdata/bitwarden.json": "successfully imported 2 documents",
+		"../internal/importer/testdata/app.env":        "successfully imported 1 documents",
+		"../internal/importer/testdata/pass":           "successfully imported 2 documents",
+	}
+
+	for file, want := range tests {
+		out := bytes.NewBufferString("")
+		if err := runCmdImportFile(out, file); err != nil {
+			t.Fatal(err)
+		}
+
+		got := strings.TrimSpace(out.String())
+		if got != want {
+			t.Fatalf("%s: expected: %s, got: %s", file, want, got)
+		}
+	}
+
+	out := bytes.NewBufferString("")
+	if err := runCmdListNamespace(out, "app"); err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.Fields(out.String())
+	want := []string{"db_host", "db_password", "db_user", "greeting"}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("expected: %v, got: %v", want, got)
+	}
+}
+
+func TestCmdImportDuplicates(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	file := filepath.Join(t.TempDir(), "keepass.csv")
+	err := os.WriteFile(file, []byte(`"Group","Title","Username","Password"
+"Root","Google","pinco.pallo","abbracadabbra"
+"Root","google","pino.lalavatrice","tu dimmi"
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := bytes.NewBufferString("")
+	if err := runCmdImportFile(out, file); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing is overwritten
+	out.Reset()
+	if err := runCmdGetArgs(out, "-n", "google", "-o", fmtJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	var got getResult
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"username":   "pinco.pallo",
+		"password":   "abbracadabbra",
+		"username_2": "pino.lalavatrice",
+		"password_2": "tu dimmi",
+	}
+	if !cmp.Equal(want, got.Secrets) {
+		t.Fatal(cmp.Diff(want, got.Secrets))
+	}
+}
+
 func runCmdImport(output io.Writer) error {
+	return runCmdImportFile(output, "../testdata/sample.yaml")
+}
+
+func runCmdImportFile(output io.Writer, file string) error {
 	op := newCmdImport()
 
 	fs := flag.NewFlagSet("", flag.ContinueOnError)
@@ -37,7 +121,7 @@ func runCmdImport(output io.Writer) error {
 
 	args := []string{
 		"-s", testStore,
-		"-f", "../testdata/sample.yaml",
+		"-f", file,
 	}
 
 	if err := fs.Parse(args); err != nil {
@@ -1,23 +1,39 @@
 package cmd
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lucasepe/locker/cmd/flags"
+	"github.com/lucasepe/locker/internal/kv"
+	"github.com/lucasepe/locker/internal/kv/bbolt"
+	"github.com/lucasepe/locker/internal/secrets"
 )
 
+const appURL = "https://github.com/lucasepe/locker"
+
 func newCmdInfo(ver, bld string) *cmdInfo {
 	return &cmdInfo{
 		appVersion: ver,
 		appBuild:   bld,
+		output:     flags.Enum{Choices: []string{fmtTxt, fmtJSON, fmtYAML}},
+		storeRef:   newStoreFlag(),
 	}
 }
 
 type cmdInfo struct {
 	appVersion string
 	appBuild   string
+	output     flags.Enum
+	storeRef   flags.Store
 }
 
 func (*cmdInfo) Name() string { return "info" }
@@ -27,32 +43,216 @@ func (*cmdInfo) Synopsis() string {
 }
 
 func (*cmdInfo) Usage() string {
-	return fmt.Sprintf("%s info", appLowerName)
+	return strings.ReplaceAll(`{NAME} info [-s store] [-o txt|json|yaml]
+
+   List all the stores:
+     {NAME} info
+
+   Show the statistics of the 'work' store (namespaces, secrets, file size,
+   format version, last change and whether the master secret unlocks it):
+     {NAME} info -s work -o json`, "{NAME}", appLowerName)
 }
 
-func (c *cmdInfo) SetFlags(fs *flag.FlagSet) {}
+func (c *cmdInfo) SetFlags(fs *flag.FlagSet) {
+	fs.Var(&c.output, "o", fmt.Sprintf("Output format, one of: %s", strings.Join(c.output.Choices, ",")))
+	fs.Var(&c.storeRef, "s", "Store name, alias or path (to show its statistics).")
+}
 
 func (p *cmdInfo) Execute(fs *flag.FlagSet) error {
-	fmt.Fprintf(fs.Output(),
-		"%s %s (build: %s) <https://github.com/lucasepe/locker>\n", appName, p.appVersion, p.appBuild)
-	archives, err := p.listStores()
+	resolveOutput(&p.output, fmtTxt)
+
+	res := infoResult{
+		Name:    appName,
+		Version: p.appVersion,
+		Build:   p.appBuild,
+		URL:     appURL,
+		Stores:  []storeInfo{},
+	}
+
+	if isFlagSet(fs, "s") {
+		stats, err := p.stats()
+		if err != nil {
+			return err
+		}
+		res.Store = stats
+
+		if structured(p.output.Value) {
+			return encodeOutput(fs.Output(), p.output.Value, res)
+		}
+
+		fmt.Fprintf(fs.Output(), "%s %s (build: %s) <%s>\n\n", res.Name, res.Version, res.Build, res.URL)
+		return stats.print(fs.Output())
+	}
+
+	archives, err := listStores()
 	if err != nil {
 		return err
 	}
-	if len(archives) == 0 {
+
+	for name, path := range archives {
+		res.Stores = append(res.Stores, storeInfo{Name: name, Path: path})
+	}
+	sort.Slice(res.Stores, func(i, j int) bool {
+		return res.Stores[i].Name < res.Stores[j].Name
+	})
+
+	if structured(p.output.Value) {
+		return encodeOutput(fs.Output(), p.output.Value, res)
+	}
+
+	fmt.Fprintf(fs.Output(), "%s %s (build: %s) <%s>\n", res.Name, res.Version, res.Build, res.URL)
+	if len(res.Stores) == 0 {
 		return nil
 	}
 
 	fmt.Fprintf(fs.Output(), "\nExisting lockers:\n\n")
 
-	for _, v := range archives {
-		fmt.Fprintf(fs.Output(), " - %s\n", v)
+	for _, el := range res.Stores {
+		fmt.Fprintf(fs.Output(), " - %s\n", el.Path)
 	}
 
 	return nil
 }
 
-func (c *cmdInfo) listStores() (map[string]string, error) {
+// infoResult is the structured output of info.
+type infoResult struct {
+	Name    string      `json:"name" yaml:"name"`
+	Version string      `json:"version" yaml:"version"`
+	Build   string      `json:"build" yaml:"build"`
+	URL     string      `json:"url" yaml:"url"`
+	Stores  []storeInfo `json:"stores" yaml:"stores"`
+	Store   *storeStats `json:"store,omitempty" yaml:"store,omitempty"`
+}
+
+type storeInfo struct {
+	Name string `json:"name" yaml:"name"`
+	Path string `json:"path" yaml:"path"`
+}
+
+// The master secret states of the store statistics.
+const (
+	// secretUnlocks: the master secret decrypts the secrets.
+	secretUnlocks = "unlocks"
+	// secretWrong: the master secret does not decrypt the secrets.
+	secretWrong = "wrong"
+	// secretMissing: no master secret has been found (or the agent is locked).
+	secretMissing = "missing"
+	// secretUnverified: the store has no secrets to check the master secret
+	// (or the master secret is held by the agent).
+	secretUnverified = "unverified"
+)
+
+// storeStats is the structured output of info -s.
+type storeStats struct {
+	Name          string     `json:"name" yaml:"name"`
+	Path          string     `json:"path" yaml:"path"`
+	Namespaces    int        `json:"namespaces" yaml:"namespaces"`
+	Secrets       int        `json:"secrets" yaml:"secrets"`
+	Size          int64      `json:"size" yaml:"size"`
+	FreePages     int        `json:"free_pages" yaml:"free_pages"`
+	FreeBytes     int        `json:"free_bytes" yaml:"free_bytes"`
+	SchemaVersion int        `json:"schema_version" yaml:"schema_version"`
+	Encryption    string     `json:"encryption" yaml:"encryption"`
+	Modified      *time.Time `json:"modified,omitempty" yaml:"modified,omitempty"`
+	MasterSecret  string     `json:"master_secret" yaml:"master_secret"`
+}
+
+// stats opens the store read-only, not to upgrade it,
+// and returns its statistics.
+func (p *cmdInfo) stats() (*storeStats, error) {
+	name := p.storeRef.Name()
+	if !p.storeRef.Exists() {
+		return nil, fmt.Errorf("store %s not found", name)
+	}
+
+	sto, err := bbolt.NewStore(bbolt.Options{
+		Path:     p.storeRef.String(),
+		ReadOnly: true,
+		Timeout:  time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := sto.(kv.Statter).Stats()
+	// closed before checking the master secret, which may open
+	// the store again (writable, when served by the agent)
+	if cerr := sto.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &storeStats{
+		Name:          name,
+		Path:          p.storeRef.String(),
+		Namespaces:    st.Namespaces,
+		Secrets:       st.Secrets,
+		Size:          st.Size,
+		FreePages:     st.FreePages,
+		FreeBytes:     st.FreeBytes,
+		SchemaVersion: st.SchemaVersion,
+		Encryption:    secrets.Scheme,
+		MasterSecret:  p.masterSecretState(),
+	}
+	if !st.Modified.IsZero() {
+		res.Modified = &st.Modified
+	}
+
+	return res, nil
+}
+
+// masterSecretState reports whether the master secret found for the
+// store (without asking it) unlocks the store.
+func (p *cmdInfo) masterSecretState() string {
+	secret, err := lookupMasterSecret(&p.storeRef)
+	if err != nil {
+		return secretMissing
+	}
+
+	// held by the agent, which opens the stores writable
+	// (upgrading them): not checked
+	if len(secret) == 0 {
+		return secretUnverified
+	}
+
+	ok, err := verifyMasterSecret(p.storeRef.String(), secret)
+
+	switch {
+	case ok:
+		return secretUnlocks
+	case errors.Is(err, ErrWrongSecret), errors.Is(err, secrets.ErrDecryptFailed):
+		return secretWrong
+	case err == nil:
+		return secretUnverified
+	}
+
+	return fmt.Sprintf("%s (%v)", secretUnverified, err)
+}
+
+func (s *storeStats) print(w io.Writer) error {
+	modified := "never"
+	if s.Modified != nil {
+		modified = s.Modified.Local().Format(time.RFC3339)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "store:\t%s\n", s.Name)
+	fmt.Fprintf(tw, "path:\t%s\n", s.Path)
+	fmt.Fprintf(tw, "namespaces:\t%d\n", s.Namespaces)
+	fmt.Fprintf(tw, "secrets:\t%d\n", s.Secrets)
+	fmt.Fprintf(tw, "size:\t%d bytes (%d free pages, %d bytes)\n", s.Size, s.FreePages, s.FreeBytes)
+	fmt.Fprintf(tw, "format version:\t%d (%s)\n", s.SchemaVersion, s.Encryption)
+	fmt.Fprintf(tw, "last modified:\t%s\n", modified)
+	fmt.Fprintf(tw, "master secret:\t%s\n", s.MasterSecret)
+
+	return tw.Flush()
+}
+
+// listStores returns the paths of the stores by name: the ones in AppDir,
+// the aliases of the configuration and the ones opened by path.
+func listStores() (map[string]string, error) {
 	dir := AppDir()
 	fp, err := os.Open(dir)
 	if err != nil {
@@ -76,5 +276,24 @@ func (c *cmdInfo) listStores() (map[string]string, error) {
 		res[key] = filepath.Join(dir, name)
 	}
 
+	aliased := map[string]bool{}
+	for name, path := range cfg.Aliases() {
+		res[name] = path
+		aliased[path] = true
+	}
+
+	registered, err := flags.Registered(dir)
+	if err != nil {
+		return res, err
+	}
+
+	for _, path := range registered {
+		// the store may have been moved or deleted
+		if _, err := os.Stat(path); err != nil || aliased[path] {
+			continue
+		}
+		res[path] = path
+	}
+
 	return res, nil
 }
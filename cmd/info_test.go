@@ -2,11 +2,15 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"flag"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/lucasepe/locker/internal/secrets"
 )
 
 func TestCmdInfo(t *testing.T) {
@@ -40,3 +44,125 @@ func runCmdInfo(output io.Writer, ver, bld string) error {
 
 	return op.Execute(fs)
 }
+
+func TestCmdInfoJSON(t *testing.T) {
+	defer func() { outputFormat.Value = "" }()
+	outputFormat.Set(fmtJSON)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdInfo(out, "1.0.0", "8888"); err != nil {
+		t.Fatal(err)
+	}
+
+	var got infoResult
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Version != "1.0.0" || got.Build != "8888" || got.Stores == nil {
+		t.Fatalf("unexpected info: %+v", got)
+	}
+}
+
+func TestCmdInfoExternalStores(t *testing.T) {
+	defer os.Remove(filepath.Join(AppDir(), "stores.txt"))
+
+	os.Setenv(EnvSecret, testSecret)
+
+	// the default store is the one of the env var
+	team := filepath.Join(t.TempDir(), "team.db")
+	t.Setenv(EnvStore, team)
+
+	op := newCmdPut()
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	op.SetFlags(fs)
+	if err := fs.Parse([]string{"-n", testNamespace, "-k", "password", "magick"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.Execute(fs); err != nil {
+		t.Fatal(err)
+	}
+
+	out := bytes.NewBufferString("")
+	if err := runCmdInfo(out, "1.0.0", "8888"); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := " - " + team + "\n"; !strings.Contains(out.String(), want) {
+		t.Fatalf("expected %q in: %s", want, out.String())
+	}
+}
+
+func TestCmdInfoStats(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "user name", "pinco.pallo@gmail.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		secret string
+		want   string
+	}{
+		{testSecret, secretUnlocks},
+		{"wrong secret", secretWrong},
+	}
+
+	for _, tc := range tests {
+		t.Setenv(EnvSecret, tc.secret)
+
+		out.Reset()
+		if err := runCmdInfoArgs(out, "-s", testStore, "-o", fmtJSON); err != nil {
+			t.Fatal(err)
+		}
+
+		var got infoResult
+		if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+
+		st := got.Store
+		if st == nil || st.Name != testStore || st.Namespaces != 1 || st.Secrets != 2 {
+			t.Fatalf("unexpected stats: %+v", st)
+		}
+		if st.Size == 0 || st.SchemaVersion == 0 || st.Modified == nil || st.Encryption != secrets.Scheme {
+			t.Fatalf("unexpected stats: %+v", st)
+		}
+		if st.MasterSecret != tc.want {
+			t.Fatalf("expected: %s, got: %s", tc.want, st.MasterSecret)
+		}
+	}
+
+	out.Reset()
+	if err := runCmdInfoArgs(out, "-s", testStore); err != nil {
+		t.Fatal(err)
+	}
+	if want := "secrets:         2\n"; !strings.Contains(out.String(), want) {
+		t.Fatalf("expected %q in: %s", want, out.String())
+	}
+
+	if err := runCmdInfoArgs(out, "-s", "nowhere"); err == nil {
+		t.Fatal("expected error on missing store")
+	}
+}
+
+func runCmdInfoArgs(output io.Writer, args ...string) error {
+	op := newCmdInfo("1.0.0", "8888")
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	op.SetFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return op.Execute(fs)
+}
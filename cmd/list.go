@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"flag"
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/lucasepe/locker/cmd/flags"
@@ -11,15 +13,15 @@ import (
 func newCmdList() *cmdList {
 	return &cmdList{
 		namespace: flags.Namespace{},
-		storeRef: flags.Store{
-			BaseDir: AppDir(),
-		},
+		storeRef:  newStoreFlag(),
+		output:    flags.Enum{Choices: []string{fmtTxt, fmtJSON, fmtYAML}},
 	}
 }
 
 type cmdList struct {
 	namespace flags.Namespace
 	storeRef  flags.Store
+	output    flags.Enum
 }
 
 func (*cmdList) Name() string { return "list" }
@@ -34,15 +36,21 @@ func (*cmdList) Usage() string {
      {NAME} list -n google -s accounts'
 
    List all namespaces in the default store:
-   {NAME} list`, "{NAME}", appLowerName)
+     {NAME} list
+
+   List all namespaces as JSON:
+     {NAME} list -o json`, "{NAME}", appLowerName)
 }
 
 func (c *cmdList) SetFlags(fs *flag.FlagSet) {
 	fs.Var(&c.namespace, "n", "Namespace.")
-	fs.Var(&c.storeRef, "s", "Store name.")
+	fs.Var(&c.storeRef, "s", "Store name, alias or path.")
+	fs.Var(&c.output, "o", fmt.Sprintf("Output format, one of: %s", strings.Join(c.output.Choices, ",")))
 }
 
 func (c *cmdList) Execute(fs *flag.FlagSet) error {
+	resolveOutput(&c.output, fmtTxt)
+
 	if len(c.namespace.Bytes()) == 0 {
 		return c.printBuckets(fs)
 	}
@@ -61,6 +69,9 @@ func (c *cmdList) printBuckets(fs *flag.FlagSet) error {
 	if err != nil {
 		return err
 	}
+	if structured(c.output.Value) {
+		return encodeOutput(fs.Output(), c.output.Value, namespacesResult{Namespaces: sorted(all)})
+	}
 	if len(all) > 0 {
 		term.PrintColumns(fs.Output(), &all, 6)
 	}
@@ -79,6 +90,10 @@ func (c *cmdList) printKeysInBucket(fs *flag.FlagSet) error {
 	if err != nil {
 		return err
 	}
+	if structured(c.output.Value) {
+		return encodeOutput(fs.Output(), c.output.Value,
+			keysResult{Namespace: c.namespace.String(), Keys: sorted(all)})
+	}
 	if len(all) == 0 {
 		return nil
 	}
@@ -87,3 +102,21 @@ func (c *cmdList) printKeysInBucket(fs *flag.FlagSet) error {
 
 	return nil
 }
+
+// namespacesResult is the structured output of list without namespace.
+type namespacesResult struct {
+	Namespaces []string `json:"namespaces" yaml:"namespaces"`
+}
+
+// keysResult is the structured output of list with a namespace.
+type keysResult struct {
+	Namespace string   `json:"namespace" yaml:"namespace"`
+	Keys      []string `json:"keys" yaml:"keys"`
+}
+
+// sorted returns a sorted, never nil, copy of the list.
+func sorted(list []string) []string {
+	res := append([]string{}, list...)
+	sort.Strings(res)
+	return res
+}
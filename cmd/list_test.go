@@ -45,6 +45,10 @@ func TestCmdList(t *testing.T) {
 }
 
 func runCmdList(output io.Writer) error {
+	return runCmdListNamespace(output, testNamespace)
+}
+
+func runCmdListNamespace(output io.Writer, namespace string) error {
 	op := newCmdList()
 
 	fs := flag.NewFlagSet("", flag.ContinueOnError)
@@ -53,7 +57,7 @@ func runCmdList(output io.Writer) error {
 	op.SetFlags(fs)
 
 	err := fs.Parse([]string{
-		"-n", testNamespace,
+		"-n", namespace,
 		"-s", testStore,
 	})
 	if err != nil {
@@ -62,3 +66,44 @@ func runCmdList(output io.Writer) error {
 
 	return op.Execute(fs)
 }
+
+func TestCmdListJSON(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "user name", "Pino Latino"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCmdPut(out, "password", "Non te la dico"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-o", "json"}, "{\n  \"namespaces\": [\n    \"stuffs\"\n  ]\n}\n"},
+		{[]string{"-n", testNamespace, "-o", "yaml"}, "namespace: stuffs\nkeys:\n  - password\n  - user_name\n"},
+	}
+
+	for _, tc := range tests {
+		out.Reset()
+		op := newCmdList()
+		fs := flag.NewFlagSet("", flag.ContinueOnError)
+		fs.SetOutput(out)
+		op.SetFlags(fs)
+
+		if err := fs.Parse(append([]string{"-s", testStore}, tc.args...)); err != nil {
+			t.Fatal(err)
+		}
+		if err := op.Execute(fs); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := out.String(); got != tc.want {
+			t.Fatalf("%v: expected: %q, got: %q", tc.args, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lucasepe/locker/internal/agent"
+)
+
+func newCmdLock() *cmdLock {
+	return &cmdLock{}
+}
+
+type cmdLock struct{}
+
+func (*cmdLock) Name() string { return "lock" }
+func (*cmdLock) Synopsis() string {
+	return "Make the agent forget the master secret and exit."
+}
+
+func (*cmdLock) Usage() string {
+	return strings.ReplaceAll(`{NAME} lock
+  
+   Lock the agent started with '{NAME} agent':
+     {NAME} lock`, "{NAME}", appLowerName)
+}
+
+func (c *cmdLock) SetFlags(fs *flag.FlagSet) {}
+
+func (c *cmdLock) Execute(fs *flag.FlagSet) error {
+	output := globalOutput()
+	sock := os.Getenv(agent.EnvSock)
+	if len(sock) == 0 {
+		return fmt.Errorf("agent not running (env var %s not set)", agent.EnvSock)
+	}
+
+	if err := agent.Lock(sock); err != nil {
+		return err
+	}
+
+	if structured(output) {
+		return encodeOutput(fs.Output(), output, statusResult{Status: "locked"})
+	}
+
+	fmt.Fprintln(fs.Output(), "agent locked")
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/lucasepe/locker/cmd/flags"
+	"github.com/lucasepe/locker/internal/kv"
+	"github.com/lucasepe/locker/internal/kv/bbolt"
+	"github.com/lucasepe/locker/internal/secrets"
+	"github.com/zalando/go-keyring"
+)
+
+// ErrWrongSecret is returned when the master secret does not unlock the store.
+var ErrWrongSecret = errors.New("wrong master secret")
+
+func newCmdLogin() *cmdLogin {
+	return &cmdLogin{
+		storeRef: newStoreFlag(),
+		in:       os.Stdin,
+	}
+}
+
+type cmdLogin struct {
+	storeRef flags.Store
+	stdin    bool
+	in       io.Reader
+}
+
+// keyringResult is the structured output of logout.
+type keyringResult struct {
+	Status string `json:"status" yaml:"status"`
+	Entry  string `json:"entry" yaml:"entry"`
+	User   string `json:"user" yaml:"user"`
+}
+
+// loginResult is the structured output of login.
+type loginResult struct {
+	keyringResult `yaml:",inline"`
+	// Verified is false if the store is empty or does not exist.
+	Verified bool `json:"verified" yaml:"verified"`
+}
+
+func (*cmdLogin) Name() string { return "login" }
+func (*cmdLogin) Synopsis() string {
+	return "Save the master secret in the OS keyring."
+}
+
+func (*cmdLogin) Usage() string {
+	return strings.ReplaceAll(`{NAME} login [flags]
+
+   Save the master secret of the 'work' store (in the LOCKER_SECRET_WORK entry):
+     {NAME} login -s work
+
+   Save the master secret used by all the stores without their own
+   (in the LOCKER_SECRET entry), checking it against the default store:
+     {NAME} login
+
+   The secret is asked on the terminal and checked against the store
+   before saving it (twice, when the store cannot verify it).`, "{NAME}", appLowerName)
+}
+
+func (c *cmdLogin) SetFlags(fs *flag.FlagSet) {
+	fs.Var(&c.storeRef, "s", "Store name, alias or path.")
+	fs.BoolVar(&c.stdin, "stdin", false, "Read the master secret from stdin.")
+}
+
+func (c *cmdLogin) Execute(fs *flag.FlagSet) error {
+	output := globalOutput()
+	service := keyringService(fs, &c.storeRef)
+	// sets the default store, if not set
+	name := c.storeRef.Name()
+
+	secret, err := c.readSecret(name)
+	if err != nil {
+		return err
+	}
+
+	verified, err := verifyMasterSecret(c.storeRef.String(), secret)
+	if err != nil {
+		return err
+	}
+
+	if !verified && !c.stdin {
+		again, err := promptMasterSecret("confirm master secret", false)
+		if err != nil {
+			return err
+		}
+		if again != secret {
+			return ErrSecretMismatch
+		}
+	}
+
+	user, err := user.Current()
+	if err != nil {
+		return err
+	}
+
+	if err := keyring.Set(service, user.Username, secret); err != nil {
+		return fmt.Errorf("unable to save the master secret in the keyring: %w", err)
+	}
+
+	if structured(output) {
+		return encodeOutput(fs.Output(), output, loginResult{
+			keyringResult: keyringResult{Status: "saved", Entry: service, User: user.Username},
+			Verified:      verified,
+		})
+	}
+
+	fmt.Fprintf(fs.Output(), "master secret saved in the keyring (entry: %s, user: %s)\n", service, user.Username)
+	if !verified {
+		fmt.Fprintf(fs.Output(), "warning: store %s is empty or does not exist, the master secret could not be verified\n", name)
+	}
+
+	return nil
+}
+
+func (c *cmdLogin) readSecret(name string) (string, error) {
+	if !c.stdin {
+		return promptMasterSecret(fmt.Sprintf("master secret of store %s", name), false)
+	}
+
+	dat, err := io.ReadAll(io.LimitReader(c.in, maxFileSize))
+	if err != nil {
+		return "", err
+	}
+
+	secret := strings.TrimRight(string(dat), "\r\n")
+	if len(secret) == 0 {
+		return "", ErrUnsetMasterSecret
+	}
+
+	return secret, nil
+}
+
+// keyringService returns the keyring entry of the store, when set with -s
+// (i.e. LOCKER_SECRET_WORK), or the one shared by all stores (LOCKER_SECRET).
+func keyringService(fs *flag.FlagSet, storeRef *flags.Store) string {
+	if isFlagSet(fs, "s") {
+		return storeSecretName(storeRef)
+	}
+	return EnvSecret
+}
+
+// verifyMasterSecret decrypts a secret of the store to check the master secret;
+// verified is false if the store does not exist or has no secrets.
+func verifyMasterSecret(path, secret string) (verified bool, err error) {
+	if _, err := os.Stat(path); err != nil {
+		return false, nil
+	}
+
+	sto, err := bbolt.NewStore(bbolt.Options{
+		Path:     path,
+		Codec:    kv.NewCryptoCodec(secret),
+		ReadOnly: true,
+		Timeout:  time.Second,
+	})
+	if err != nil {
+		return false, err
+	}
+	defer sto.Close()
+
+	return checkSecret(sto)
+}
+
+// checkSecret decrypts the first secret of the store;
+// verified is false if the store has no secrets.
+func checkSecret(sto kv.Store) (verified bool, err error) {
+	all, err := sto.Namespaces()
+	if err != nil {
+		return false, err
+	}
+
+	for _, ns := range all {
+		keys, err := sto.Keys(ns)
+		if err != nil {
+			return false, err
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		_, err = sto.GetOne(ns, keys[0])
+		if errors.Is(err, secrets.ErrDecryptFailed) {
+			return false, ErrWrongSecret
+		}
+		return err == nil, err
+	}
+
+	return false, nil
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"os/user"
+	"strings"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestCmdLoginLogout(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	keyring.MockInit()
+
+	u, err := user.Current()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runCmdLogin(out, "wrong secret\n"); !errors.Is(err, ErrWrongSecret) {
+		t.Fatalf("expected: %v, got: %v", ErrWrongSecret, err)
+	}
+	if _, err := keyring.Get("LOCKER_SECRET_TEST", u.Username); err != keyring.ErrNotFound {
+		t.Fatalf("expected: %v, got: %v", keyring.ErrNotFound, err)
+	}
+
+	out.Reset()
+	if err := runCmdLogin(out, testSecret+"\n"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "master secret saved in the keyring (entry: LOCKER_SECRET_TEST") {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+
+	// the store is unlocked by the keyring entry
+	os.Unsetenv(EnvSecret)
+	out.Reset()
+	if err := runCmdGet(out, "password"); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "magick" {
+		t.Fatalf("expected: magick, got: %s", got)
+	}
+
+	if err := runCmdLogout(out); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keyring.Get("LOCKER_SECRET_TEST", u.Username); err != keyring.ErrNotFound {
+		t.Fatalf("expected: %v, got: %v", keyring.ErrNotFound, err)
+	}
+	if err := runCmdLogout(out); err == nil {
+		t.Fatal("expected error logging out twice")
+	}
+}
+
+func runCmdLogin(output io.Writer, secret string) error {
+	op := newCmdLogin()
+	op.in = strings.NewReader(secret)
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	op.SetFlags(fs)
+
+	if err := fs.Parse([]string{"-s", testStore, "-stdin"}); err != nil {
+		return err
+	}
+
+	return op.Execute(fs)
+}
+
+func runCmdLogout(output io.Writer) error {
+	op := newCmdLogout()
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	op.SetFlags(fs)
+
+	if err := fs.Parse([]string{"-s", testStore}); err != nil {
+		return err
+	}
+
+	return op.Execute(fs)
+}
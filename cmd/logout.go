@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os/user"
+	"strings"
+
+	"github.com/lucasepe/locker/cmd/flags"
+	"github.com/zalando/go-keyring"
+)
+
+func newCmdLogout() *cmdLogout {
+	return &cmdLogout{
+		storeRef: newStoreFlag(),
+	}
+}
+
+type cmdLogout struct {
+	storeRef flags.Store
+}
+
+func (*cmdLogout) Name() string { return "logout" }
+func (*cmdLogout) Synopsis() string {
+	return "Remove the master secret from the OS keyring."
+}
+
+func (*cmdLogout) Usage() string {
+	return strings.ReplaceAll(`{NAME} logout [flags]
+
+   Remove the master secret of the 'work' store (the LOCKER_SECRET_WORK entry):
+     {NAME} logout -s work
+
+   Remove the master secret shared by all stores (the LOCKER_SECRET entry):
+     {NAME} logout`, "{NAME}", appLowerName)
+}
+
+func (c *cmdLogout) SetFlags(fs *flag.FlagSet) {
+	fs.Var(&c.storeRef, "s", "Store name, alias or path.")
+}
+
+func (c *cmdLogout) Execute(fs *flag.FlagSet) error {
+	output := globalOutput()
+	service := keyringService(fs, &c.storeRef)
+
+	user, err := user.Current()
+	if err != nil {
+		return err
+	}
+
+	err = keyring.Delete(service, user.Username)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("master secret not found in the keyring (entry: %s, user: %s)", service, user.Username)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to remove the master secret from the keyring: %w", err)
+	}
+
+	if structured(output) {
+		return encodeOutput(fs.Output(), output,
+			keyringResult{Status: "removed", Entry: service, User: user.Username})
+	}
+
+	fmt.Fprintf(fs.Output(), "master secret removed from the keyring (entry: %s, user: %s)\n", service, user.Username)
+
+	return nil
+}
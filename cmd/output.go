@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/lucasepe/locker/cmd/flags"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// outputFormat is the output format set before the command name
+	// (i.e. 'locker -o json list'); the -o flag of the command wins.
+	outputFormat = flags.Enum{Choices: []string{fmtTxt, fmtJSON, fmtYAML}}
+	// errorFormat is the output format of the command being executed.
+	errorFormat string
+)
+
+// resolveOutput sets the output format of the command, when not specified,
+// to the global one or to the one of the configuration file (or to def,
+// when not supported by the command); errors are then reported in the
+// structured format chosen, if any.
+func resolveOutput(output *flags.Enum, def string) {
+	if output.Value == "" {
+		switch {
+		case contains(output.Choices, outputFormat.Value):
+			output.Set(outputFormat.Value)
+		case contains(output.Choices, cfg.Output):
+			output.Set(cfg.Output)
+		default:
+			output.Set(def)
+		}
+	}
+
+	errorFormat = output.Value
+}
+
+// globalOutput returns the output format of the commands without an -o flag:
+// the global one or the one of the configuration file, if structured.
+func globalOutput() string {
+	output := flags.Enum{Choices: outputFormat.Choices}
+	resolveOutput(&output, fmtTxt)
+	return output.Value
+}
+
+// statusResult is the structured result of the commands changing a store
+// (or the keyring, or the agent).
+type statusResult struct {
+	Status    string `json:"status" yaml:"status"`
+	Store     string `json:"store,omitempty" yaml:"store,omitempty"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Key       string `json:"key,omitempty" yaml:"key,omitempty"`
+}
+
+// structured reports whether the format is machine-readable.
+func structured(format string) bool {
+	return format == fmtJSON || format == fmtYAML
+}
+
+// encodeOutput writes v in the structured format; map keys are always sorted.
+func encodeOutput(w io.Writer, format string, v any) error {
+	if format == fmtYAML {
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// errorResult is the structured form of an error.
+type errorResult struct {
+	Error string `json:"error" yaml:"error"`
+}
+
+// reportError writes the error in the structured output format, if any,
+// and returns an ExitError so that it is not reported twice.
+func reportError(w io.Writer, err error) error {
+	format := errorFormat
+	if len(format) == 0 {
+		format = outputFormat.Value
+	}
+	if len(format) == 0 {
+		format = cfg.Output
+	}
+
+	if err == nil || !structured(format) {
+		return err
+	}
+
+	if _, ok := err.(*ExitError); ok {
+		return err
+	}
+
+	if e := encodeOutput(w, format, errorResult{Error: err.Error()}); e != nil {
+		return err
+	}
+
+	return &ExitError{Code: 1}
+}
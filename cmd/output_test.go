@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestReportError(t *testing.T) {
+	defer func() {
+		outputFormat.Value = ""
+		errorFormat = ""
+	}()
+	outputFormat.Value, errorFormat = "", ""
+
+	out := bytes.NewBufferString("")
+	err := errors.New("namespace not found")
+
+	if got := reportError(out, err); got != err || out.Len() > 0 {
+		t.Fatalf("expected the error unchanged, got: %v (%q)", got, out.String())
+	}
+
+	outputFormat.Set(fmtJSON)
+	got := reportError(out, err)
+
+	var ee *ExitError
+	if !errors.As(got, &ee) || ee.Code != 1 {
+		t.Fatalf("expected exit status 1, got: %v", got)
+	}
+
+	want := "{\n  \"error\": \"namespace not found\"\n}\n"
+	if out.String() != want {
+		t.Fatalf("expected: %q, got: %q", want, out.String())
+	}
+
+	// the -o flag of the command wins
+	out.Reset()
+	errorFormat = fmtYAML
+	reportError(out, err)
+
+	want = "error: namespace not found\n"
+	if out.String() != want {
+		t.Fatalf("expected: %q, got: %q", want, out.String())
+	}
+}
+
+func TestStatusOutput(t *testing.T) {
+	defer os.Remove(testArchivePath())
+	defer func() {
+		outputFormat.Value = ""
+		errorFormat = ""
+	}()
+
+	os.Setenv(EnvSecret, testSecret)
+	outputFormat.Set(fmtJSON)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  "status": "stored",
+  "store": "test.db",
+  "namespace": "stuffs",
+  "key": "password"
+}
+`
+	if out.String() != want {
+		t.Fatalf("expected: %q, got: %q", want, out.String())
+	}
+
+	out.Reset()
+	if err := runCmdDelete(out, "password"); err != nil {
+		t.Fatal(err)
+	}
+
+	want = `{
+  "status": "deleted",
+  "deleted": [
+    {
+      "namespace": "stuffs",
+      "key": "password"
+    }
+  ]
+}
+`
+	if out.String() != want {
+		t.Fatalf("expected: %q, got: %q", want, out.String())
+	}
+}
@@ -13,9 +13,7 @@ func newCmdPut() *cmdPut {
 	return &cmdPut{
 		namespace: flags.Namespace{},
 		key:       flags.Key{},
-		storeRef: flags.Store{
-			BaseDir: AppDir(),
-		},
+		storeRef:  newStoreFlag(),
 	}
 }
 
@@ -23,6 +21,7 @@ type cmdPut struct {
 	namespace flags.Namespace
 	key       flags.Key
 	storeRef  flags.Store
+	output    string
 }
 
 func (*cmdPut) Name() string { return "put" }
@@ -40,12 +39,15 @@ func (*cmdPut) Usage() string {
      cat doc.txt | {NAME} put -n docs -k my_doc
 
    Put a secret whose content is another command output (using pipes):
-     pwgen 14 1 | {NAME} put -n Instagram -k password`, "{NAME}", appLowerName)
+     pwgen 14 1 | {NAME} put -n Instagram -k password
+
+   Or generate it with the built-in generator (see '{NAME} help gen'):
+     {NAME} gen -store -n Instagram -k password`, "{NAME}", appLowerName)
 }
 
 func (c *cmdPut) SetFlags(fs *flag.FlagSet) {
 	fs.Var(&c.namespace, "n", "Namespace.")
-	fs.Var(&c.storeRef, "s", "Store name.")
+	fs.Var(&c.storeRef, "s", "Store name, alias or path.")
 	fs.Var(&c.key, "k", "Secret key.")
 }
 
@@ -66,16 +68,32 @@ func (c *cmdPut) Execute(fs *flag.FlagSet) error {
 	defer sto.Close()
 
 	err = sto.PutOne(c.namespace.String(), c.key.String(), string(val))
-	if err == nil {
+	if err != nil {
+		return err
+	}
+
+	if err := recordAccess(sto, "put", c.namespace.String(), c.key.String()); err != nil {
+		return err
+	}
 
-		fmt.Fprintf(fs.Output(), "secret successfully stored (key:%s, namespace: %s, store: %s)\n",
-			c.key.String(), c.namespace.String(), filepath.Base(c.storeRef.String()))
+	if structured(c.output) {
+		return encodeOutput(fs.Output(), c.output, statusResult{
+			Status:    "stored",
+			Store:     filepath.Base(c.storeRef.String()),
+			Namespace: c.namespace.String(),
+			Key:       c.key.String(),
+		})
 	}
 
-	return err
+	fmt.Fprintf(fs.Output(), "secret successfully stored (key:%s, namespace: %s, store: %s)\n",
+		c.key.String(), c.namespace.String(), filepath.Base(c.storeRef.String()))
+
+	return nil
 }
 
 func (c *cmdPut) complete(fs *flag.FlagSet) error {
+	c.output = globalOutput()
+
 	if len(c.namespace.Bytes()) == 0 {
 		return fmt.Errorf("missing namespace")
 	}
@@ -84,7 +102,7 @@ func (c *cmdPut) complete(fs *flag.FlagSet) error {
 		return fmt.Errorf("missing key")
 	}
 
-	pwd, err := getMasterSecret()
+	pwd, err := getMasterSecret(&c.storeRef)
 	if err != nil {
 		return err
 	}
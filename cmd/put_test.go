@@ -55,6 +55,33 @@ func runCmdPut(output io.Writer, k, v string) error {
 	return op.Execute(fs)
 }
 
+func runCmdPutArgs(output io.Writer, args ...string) error {
+	op := newCmdPut()
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	op.SetFlags(fs)
+
+	if err := fs.Parse(append([]string{"-s", testStore}, args...)); err != nil {
+		return err
+	}
+
+	return op.Execute(fs)
+}
+
 func testArchivePath() string {
 	return filepath.Join(AppDir(), fmt.Sprintf("%s.db", testStore))
 }
+
+func TestCmdPutWithoutSecret(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	// no env var and stdin is not a terminal: no prompt
+	t.Setenv(EnvSecret, "")
+
+	err := runCmdPut(bytes.NewBufferString(""), "password", "magick")
+	if err != ErrUnsetMasterSecret {
+		t.Fatalf("expected: %v, got: %v", ErrUnsetMasterSecret, err)
+	}
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/lucasepe/locker/cmd/flags"
+	"github.com/lucasepe/locker/internal/kv"
+	"github.com/lucasepe/strcase"
+)
+
+func newCmdRender() *cmdRender {
+	return &cmdRender{
+		output:   flags.FileFlag{},
+		storeRef: newStoreFlag(),
+	}
+}
+
+type cmdRender struct {
+	output   flags.FileFlag
+	storeRef flags.Store
+	// format is the output format of the result, when writing to a file.
+	format string
+}
+
+// renderResult is the structured output of render, when writing to a file.
+type renderResult struct {
+	Status string `json:"status" yaml:"status"`
+	File   string `json:"file" yaml:"file"`
+}
+
+func (*cmdRender) Name() string { return "render" }
+func (*cmdRender) Synopsis() string {
+	return "Render a template filled with secrets."
+}
+
+func (*cmdRender) Usage() string {
+	return strings.ReplaceAll(`{NAME} render [flags] <template>
+  
+   Templates use the Go text/template syntax plus the functions:
+     {{ secret "ns" "key" }}  the value of a secret
+     {{ namespace "ns" }}     all the secrets of a namespace (a key/value map)
+     {{ totp "ns" }}          a time-based OTP from the 'totp' key of a namespace
+
+   Render '.npmrc.tmpl' into '.npmrc':
+     {NAME} render -o .npmrc .npmrc.tmpl
+
+   where '.npmrc.tmpl' contains:
+     //registry.npmjs.org/:_authToken={{ secret "npm" "token" }}`, "{NAME}", appLowerName)
+}
+
+func (c *cmdRender) SetFlags(fs *flag.FlagSet) {
+	fs.Var(&c.storeRef, "s", "Store name, alias or path.")
+	fs.Var(&c.output, "o", "Output file (stdout if omitted).")
+}
+
+func (c *cmdRender) Execute(fs *flag.FlagSet) error {
+	if err := c.complete(fs); err != nil {
+		return err
+	}
+
+	src, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	sto, err := c.storeRef.Connect()
+	if err != nil {
+		return err
+	}
+	defer sto.Close()
+
+	fn := &renderFuncs{sto: sto, accessed: map[string][]string{}}
+	tpl, err := template.New(fs.Arg(0)).
+		Option("missingkey=error").
+		Funcs(fn.FuncMap()).
+		Parse(string(src))
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	err = tpl.Execute(&buf, nil)
+	if rerr := fn.record(); rerr != nil {
+		return rerr
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(c.output.String()) == 0 {
+		_, err = fs.Output().Write(buf.Bytes())
+		return err
+	}
+
+	if err := writePrivateFile(c.output.String(), buf.Bytes()); err != nil {
+		return err
+	}
+
+	if structured(c.format) {
+		return encodeOutput(fs.Output(), c.format, renderResult{
+			Status: "rendered",
+			File:   c.output.String(),
+		})
+	}
+
+	return nil
+}
+
+func (c *cmdRender) complete(fs *flag.FlagSet) error {
+	c.format = globalOutput()
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("missing template")
+	}
+
+	pwd, err := getMasterSecret(&c.storeRef)
+	if err != nil {
+		return err
+	}
+	c.storeRef.MasterSecret = pwd
+
+	return nil
+}
+
+// renderFuncs holds the template functions looking up the secrets.
+type renderFuncs struct {
+	sto      kv.Store
+	accessed map[string][]string
+}
+
+func (f *renderFuncs) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"secret":    f.secret,
+		"namespace": f.namespace,
+		"totp":      f.totp,
+	}
+}
+
+// secret, namespace and totp normalize the names as the -n and -k flags.
+func (f *renderFuncs) secret(namespace, key string) (string, error) {
+	namespace, key = strcase.Kebab(namespace), strcase.Snake(key)
+
+	all, err := f.sto.GetAll(namespace, key)
+	if err != nil {
+		return "", fmt.Errorf("namespace: %s: %w", namespace, err)
+	}
+
+	val, ok := all[key]
+	if !ok {
+		return "", fmt.Errorf("secret not found (namespace: %s, key: %s)", namespace, key)
+	}
+
+	f.accessed[namespace] = append(f.accessed[namespace], key)
+	return val, nil
+}
+
+func (f *renderFuncs) namespace(namespace string) (map[string]string, error) {
+	namespace = strcase.Kebab(namespace)
+
+	all, err := f.sto.GetAll(namespace)
+	if err != nil {
+		return nil, fmt.Errorf("namespace: %s: %w", namespace, err)
+	}
+
+	for k := range all {
+		f.accessed[namespace] = append(f.accessed[namespace], k)
+	}
+	return all, nil
+}
+
+func (f *renderFuncs) totp(namespace string) (string, error) {
+	uri, err := f.secret(namespace, "totp")
+	if err != nil {
+		return "", err
+	}
+
+	return generateTotp(uri)
+}
+
+// record writes the accessed secrets to the audit log.
+func (f *renderFuncs) record() error {
+	for ns, keys := range f.accessed {
+		sort.Strings(keys)
+		if err := recordAccess(f.sto, "render", ns, keys...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePrivateFile writes data to the named file, readable
+// and writable only by the owner.
+func writePrivateFile(name string, data []byte) error {
+	fp, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	// the file may already exist with looser permissions
+	if err := fp.Chmod(0600); err != nil {
+		fp.Close()
+		return err
+	}
+
+	if _, err := fp.Write(data); err != nil {
+		fp.Close()
+		return err
+	}
+
+	return fp.Close()
+}
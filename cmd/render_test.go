@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCmdRender(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "user name", "pinco"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "pgpass.tmpl")
+	dst := filepath.Join(dir, "pgpass")
+
+	tmpl := `localhost:5432:*:{{ secret "stuffs" "user_name" }}:{{ secret "stuffs" "password" }}
+{{ range $k, $v := namespace "stuffs" }}{{ $k }} {{ end }}`
+	if err := os.WriteFile(src, []byte(tmpl), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := runCmdRender(out, src, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "localhost:5432:*:pinco:magick\npassword user_name "
+	if string(got) != want {
+		t.Fatalf("expected: %q, got: %q", want, got)
+	}
+
+	fi, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0600 {
+		t.Fatalf("expected permissions: 0600, got: %o", perm)
+	}
+}
+
+func TestCmdRenderNormalizesNames(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "user name", "pinco"); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "app.tmpl")
+	dst := filepath.Join(dir, "app.yaml")
+
+	// as with get -n Stuffs -k "user name"
+	tmpl := `user: {{ secret "Stuffs" "user name" }}{{ range $k, $v := namespace "Stuffs" }} {{ $k }}{{ end }}`
+	if err := os.WriteFile(src, []byte(tmpl), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := runCmdRender(out, src, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "user: pinco user_name"; string(got) != want {
+		t.Fatalf("expected: %q, got: %q", want, got)
+	}
+}
+
+func TestCmdRenderMissingSecret(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "app.tmpl")
+	dst := filepath.Join(dir, "app.yaml")
+
+	tmpl := `token: {{ secret "stuffs" "token" }}`
+	if err := os.WriteFile(src, []byte(tmpl), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	err := runCmdRender(out, src, dst)
+	if err == nil || !strings.Contains(err.Error(), "secret not found") {
+		t.Fatalf("expected secret not found error, got: %v", err)
+	}
+
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Fatalf("expected no output file, got: %v", err)
+	}
+}
+
+func runCmdRender(output io.Writer, src, dst string) error {
+	op := newCmdRender()
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	op.SetFlags(fs)
+
+	args := []string{
+		"-s", testStore,
+		"-o", dst,
+		src,
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return op.Execute(fs)
+}
@@ -10,14 +10,20 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/lucasepe/locker/cmd/flags"
+	"github.com/lucasepe/locker/internal/agent"
+	"github.com/lucasepe/locker/internal/envvar"
 	"github.com/lucasepe/locker/internal/text"
 	"github.com/lucasepe/subcommands"
 	"github.com/lucasepe/xdg"
 	"github.com/zalando/go-keyring"
+	"golang.org/x/term"
 )
 
 const (
 	EnvSecret = "LOCKER_SECRET"
+	// EnvStore is the env var with the default store (name, alias or path).
+	EnvStore = "LOCKER_STORE"
 
 	banner = `┬  ┌─┐┌─┐┬┌─┌─┐┬─┐
 │  │ ││  ├┴┐├┤ ├┬┘
@@ -31,9 +37,37 @@ const (
 var (
 	ErrUnsetMasterSecret = fmt.Errorf(
 		"specify a master secret setting the env var: %s", EnvSecret)
+	ErrNotTerminal    = fmt.Errorf("stdin is not a terminal")
+	ErrSecretMismatch = fmt.Errorf("master secrets do not match")
 )
 
+// ExitError is returned when a command must terminate the process
+// with a specific exit code (i.e. the one of a child process).
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
 func Run(ver, bld string) error {
+	// the background process clearing the clipboard
+	if timeout := os.Getenv(envClipClear); len(timeout) > 0 {
+		return clearClipboard(timeout)
+	}
+
+	configErr := loadConfig()
+
+	// the hidden command used by the completion scripts
+	if len(os.Args) > 1 && os.Args[1] == completeCmdName {
+		return complete(os.Stdout, os.Args[2:])
+	}
+
+	if configErr != nil {
+		return configErr
+	}
+
 	err := os.MkdirAll(AppDir(), os.ModePerm)
 	if err != nil {
 		return err
@@ -41,22 +75,61 @@ func Run(ver, bld string) error {
 
 	cli := subcommands.New(flag.CommandLine, appLowerName)
 	cli.Banner = fmt.Sprintf("%s\n%s\n", banner, summary)
-	if _, err := getMasterSecret(); err != nil {
+	if _, err := lookupMasterSecret(nil); err != nil {
 		cli.Banner = fmt.Sprintf("%s\n> %s\n", cli.Banner, err)
 	}
 	cli.Register(cli.HelpCommand(), "")
 
-	cli.Register(newCmdGet(), "")
-	cli.Register(newCmdPut(), "")
-	cli.Register(newCmdList(), "")
-	cli.Register(newCmdInfo(ver, bld), "")
-	cli.Register(newCmdDelete(), "")
-	cli.Register(newCmdImport(), "")
-	cli.Register(newCmdTotp(), "")
+	for _, el := range commands(ver, bld) {
+		cli.Register(el, "")
+	}
 
+	setGlobalFlags(flag.CommandLine)
 	flag.Parse()
 
-	return cli.Execute()
+	return reportError(os.Stderr, cli.Execute())
+}
+
+// commands returns all the commands, in the order they are listed by help.
+func commands(ver, bld string) []subcommands.Command {
+	return []subcommands.Command{
+		newCmdGet(),
+		newCmdPut(),
+		newCmdEdit(),
+		newCmdList(),
+		newCmdInfo(ver, bld),
+		newCmdDelete(),
+		newCmdImport(),
+		newCmdExport(),
+		newCmdTotp(),
+		newCmdAudit(),
+		newCmdAuditPasswords(),
+		newCmdBreachCheck(),
+		newCmdSync(),
+		newCmdExec(),
+		newCmdRender(),
+		newCmdGen(),
+		newCmdShell(),
+		newCmdAgent(),
+		newCmdLock(),
+		newCmdLogin(),
+		newCmdLogout(),
+		newCmdCompletion(),
+	}
+}
+
+// setGlobalFlags defines the flags set before the command name.
+func setGlobalFlags(fs *flag.FlagSet) {
+	fs.Var(&outputFormat, "o",
+		fmt.Sprintf("Output format of all commands, one of: %s", strings.Join(outputFormat.Choices, ",")))
+}
+
+// isFlagSet reports whether the flag has been set on the command line.
+func isFlagSet(fs *flag.FlagSet, name string) (res bool) {
+	fs.Visit(func(fl *flag.Flag) {
+		res = res || fl.Name == name
+	})
+	return res
 }
 
 func AppDir() string {
@@ -96,21 +169,161 @@ func grabContent(fs *flag.FlagSet) []byte {
 	return dat
 }
 
-func getMasterSecret() (string, error) {
+// confirm asks a yes/no question on the terminal;
+// it fails with ErrNotTerminal when stdin is not a terminal.
+func confirm(question string) (bool, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, ErrNotTerminal
+	}
+
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	ans, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	ans = strings.ToLower(strings.TrimSpace(ans))
+	return ans == "y" || ans == "yes", nil
+}
+
+// getMasterSecret looks up the master secret of the store and, when not found,
+// asks for it on the terminal (twice if the store has not been created yet);
+// it fails with ErrWrongSecret if the secret does not unlock the store
+// and with any other error preventing to check it.
+func getMasterSecret(storeRef *flags.Store) (string, error) {
+	secret, err := lookupMasterSecret(storeRef)
+	if err == ErrUnsetMasterSecret {
+		prompt := "master secret"
+		if storeRef != nil {
+			prompt = fmt.Sprintf("master secret of store %s", storeRef.Name())
+		}
+
+		secret, err = promptMasterSecret(prompt, storeRef != nil && !storeRef.Exists())
+		if err == ErrNotTerminal {
+			return "", ErrUnsetMasterSecret
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// not to write secrets encrypted with another key (i.e. it fails with
+	// ErrWrongSecret or if the store is locked by another process); only
+	// the stores without secrets, which cannot be verified, are tolerated;
+	// held by the agent when empty
+	if storeRef != nil && len(secret) > 0 && storeRef.Exists() {
+		if _, err := verifyMasterSecret(storeRef.String(), secret); err != nil {
+			return "", fmt.Errorf("store %s: %w", storeRef.Name(), err)
+		}
+	}
+
+	return secret, nil
+}
+
+// lookupMasterSecret looks up the master secret of the store: first in the
+// sources of the store, the env var (i.e. LOCKER_SECRET_WORK), the source set
+// in the configuration file and the OS keyring entry of the store; then in the
+// ones shared by all stores, LOCKER_SECRET, the agent and the LOCKER_SECRET
+// keyring entry; the secret is empty if held by the agent.
+func lookupMasterSecret(storeRef *flags.Store) (string, error) {
+	var name string
+	if storeRef != nil {
+		name = storeSecretName(storeRef)
+		if secret := os.Getenv(name); len(secret) != 0 {
+			return secret, nil
+		}
+	}
+
+	if secret, ok, err := configSecret(storeRef); ok {
+		return secret, err
+	}
+
+	if len(name) != 0 {
+		if secret, err := keyringSecret(name); err == nil {
+			return secret, nil
+		}
+	}
+
 	secret := os.Getenv(EnvSecret)
 	if len(secret) != 0 {
 		return secret, nil
 	}
 
+	// the agent holds the secret
+	if len(os.Getenv(agent.EnvSock)) != 0 {
+		return "", nil
+	}
+
+	if secret, err := keyringSecret(EnvSecret); err == nil {
+		return secret, nil
+	}
+
+	return "", ErrUnsetMasterSecret
+}
+
+// storeSecretName returns the name of the env var, and of the keyring
+// entry, holding the master secret of the store (i.e. LOCKER_SECRET_WORK).
+func storeSecretName(storeRef *flags.Store) string {
+	name := storeRef.Name()
+	// opened by path
+	if filepath.IsAbs(name) {
+		name = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	}
+
+	return envvar.Name(EnvSecret+"_", name)
+}
+
+// keyringSecret returns the secret of the current user
+// stored in the OS keyring for the service.
+func keyringSecret(service string) (string, error) {
 	user, err := user.Current()
 	if err != nil {
 		return "", err
 	}
 
-	secret, err = keyring.Get(EnvSecret, user.Username)
-	if err == nil {
-		return secret, nil
+	return keyring.Get(service, user.Username)
+}
+
+// promptMasterSecret reads the master secret from the terminal without
+// echoing it; when confirm is true the secret must be typed twice.
+func promptMasterSecret(prompt string, confirm bool) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", ErrNotTerminal
 	}
 
-	return "", ErrUnsetMasterSecret
+	if confirm {
+		prompt += " (new store)"
+	}
+
+	secret, err := readSecret(fd, prompt+": ")
+	if err != nil {
+		return "", err
+	}
+	if len(secret) == 0 {
+		return "", ErrUnsetMasterSecret
+	}
+
+	if confirm {
+		again, err := readSecret(fd, "confirm master secret: ")
+		if err != nil {
+			return "", err
+		}
+		if again != secret {
+			return "", ErrSecretMismatch
+		}
+	}
+
+	return secret, nil
+}
+
+func readSecret(fd int, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	dat, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(dat), nil
 }
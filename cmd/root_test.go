@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lucasepe/locker/internal/agent"
+	"github.com/lucasepe/locker/internal/config"
+	"github.com/lucasepe/locker/internal/kv/bbolt"
+	"github.com/zalando/go-keyring"
+)
+
+func TestLookupMasterSecretPerStore(t *testing.T) {
+	keyring.MockInit()
+
+	u, err := user.Current()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keyring.Set("LOCKER_SECRET_TEAM", u.Username, "team secret"); err != nil {
+		t.Fatal(err)
+	}
+	defer keyring.Delete("LOCKER_SECRET_TEAM", u.Username)
+
+	defer func() { cfg = &config.Config{} }()
+	cfg = &config.Config{
+		Stores: map[string]config.Store{
+			"personal": {Secret: "env:MY_PERSONAL_SECRET"},
+		},
+	}
+
+	t.Setenv(agent.EnvSock, "")
+	t.Setenv("LOCKER_SECRET_WORK", "work secret")
+	t.Setenv("MY_PERSONAL_SECRET", "personal secret")
+
+	tests := []struct {
+		store   string
+		generic string
+		want    string
+	}{
+		{"work", "generic secret", "work secret"},
+		{filepath.Join(t.TempDir(), "work.db"), "generic secret", "work secret"},
+		{"personal", "generic secret", "personal secret"},
+		{"personal", "", "personal secret"},
+		{"team", "generic secret", "team secret"},
+		{"team", "", "team secret"},
+		{"other", "generic secret", "generic secret"},
+	}
+
+	for _, tc := range tests {
+		t.Setenv(EnvSecret, tc.generic)
+
+		storeRef := newStoreFlag()
+		if err := storeRef.Set(tc.store); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := lookupMasterSecret(&storeRef)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.store, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%s: expected: %s, got: %s", tc.store, tc.want, got)
+		}
+	}
+
+	t.Setenv(EnvSecret, "")
+	storeRef := newStoreFlag()
+	storeRef.Set("other")
+	if _, err := lookupMasterSecret(&storeRef); err != ErrUnsetMasterSecret {
+		t.Fatalf("expected: %v, got: %v", ErrUnsetMasterSecret, err)
+	}
+}
+
+func TestGetMasterSecretWrongSecret(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	t.Setenv(agent.EnvSock, "")
+	t.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing is written with another key
+	t.Setenv(EnvSecret, "wrong secret")
+	if err := runCmdPut(out, "user", "pinco.pallo"); !errors.Is(err, ErrWrongSecret) {
+		t.Fatalf("expected: %v, got: %v", ErrWrongSecret, err)
+	}
+
+	t.Setenv(EnvSecret, testSecret)
+	out.Reset()
+	if err := runCmdListNamespace(out, testNamespace); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(out.String()); len(got) != 1 || got[0] != "password" {
+		t.Fatalf("unexpected keys: %v", got)
+	}
+}
+
+func TestGetMasterSecretLockedStore(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	t.Setenv(agent.EnvSock, "")
+	t.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	// held by another process
+	sto, err := bbolt.NewStore(bbolt.Options{Path: testArchivePath()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sto.Close()
+
+	storeRef := newStoreFlag()
+	storeRef.Set(testStore)
+	if _, err := getMasterSecret(&storeRef); err == nil {
+		t.Fatal("expected error checking the secret of a locked store")
+	}
+}
@@ -0,0 +1,573 @@
+package cmd
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lucasepe/locker/cmd/flags"
+	"github.com/lucasepe/locker/internal/kv"
+	"github.com/lucasepe/strcase"
+	"golang.org/x/term"
+)
+
+// shellCommands are the commands available in the shell session.
+var shellCommands = map[string]struct {
+	usage    string
+	synopsis string
+}{
+	"get":    {"get <namespace> [key...]", "Print one, some or all secrets of a namespace."},
+	"put":    {"put <namespace> <key> [value]", "Store a secret (the value is asked if omitted)."},
+	"list":   {"list [namespace]", "List all namespaces or all keys in a namespace."},
+	"delete": {"delete [-y] <namespace> [key]", "Delete a secret or a whole namespace (-y: without confirmation)."},
+	"totp":   {"totp <namespace>", "Generate a time-based OTP from the 'totp' key of a namespace."},
+	"search": {"search <text>", "Find the namespaces and keys containing the text."},
+	"help":   {"help", "Show this help."},
+	"exit":   {"exit", "Lock the store and leave the session."},
+}
+
+func newCmdShell() *cmdShell {
+	return &cmdShell{
+		storeRef: newStoreFlag(),
+		in:       os.Stdin,
+	}
+}
+
+type cmdShell struct {
+	storeRef flags.Store
+	timeout  time.Duration
+	in       io.Reader
+
+	sto kv.Store
+	out io.Writer
+	// term is nil when stdin is not a terminal.
+	term *term.Terminal
+}
+
+func (*cmdShell) Name() string { return "shell" }
+func (*cmdShell) Synopsis() string {
+	return "Start an interactive session that unlocks the store once."
+}
+
+func (*cmdShell) Usage() string {
+	return strings.ReplaceAll(`{NAME} shell [flags]
+
+   Start a session on the default store, locked after 5 minutes of inactivity:
+     {NAME} shell
+
+   Start a session on the 'accounts' store, locked after 30 seconds of inactivity:
+     {NAME} shell -s accounts -timeout 30s
+
+   Type 'help' in the session to list the available commands;
+   namespaces and keys are completed pressing TAB.`, "{NAME}", appLowerName)
+}
+
+func (c *cmdShell) SetFlags(fs *flag.FlagSet) {
+	fs.Var(&c.storeRef, "s", "Store name, alias or path.")
+	fs.DurationVar(&c.timeout, "timeout", lockTimeout(5*time.Minute), "Lock the store after this idle time (0 to disable).")
+}
+
+func (c *cmdShell) Execute(fs *flag.FlagSet) error {
+	if err := c.complete(fs); err != nil {
+		return err
+	}
+
+	sto, err := c.storeRef.Connect()
+	if err != nil {
+		return err
+	}
+	defer sto.Close()
+	c.sto = sto
+
+	readLine, restore, err := c.open(fs)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	type result struct {
+		line string
+		err  error
+	}
+
+	for {
+		lines := make(chan result, 1)
+		go func() {
+			line, err := readLine()
+			lines <- result{line, err}
+		}()
+
+		var idle <-chan time.Time
+		timer := time.NewTimer(c.timeout)
+		if c.timeout > 0 {
+			idle = timer.C
+		}
+
+		var res result
+		select {
+		case res = <-lines:
+			timer.Stop()
+		case <-idle:
+			fmt.Fprintf(c.out, "store locked after %s of inactivity\n", c.timeout)
+			return nil
+		}
+
+		if res.err != nil {
+			if res.err == io.EOF {
+				return nil
+			}
+			return res.err
+		}
+
+		quit, err := c.run(res.line)
+		if err != nil {
+			fmt.Fprintf(c.out, "error: %s\n", err)
+		}
+		if quit {
+			return nil
+		}
+	}
+}
+
+// open prepares the session input and output; when stdin is a terminal
+// it is switched to raw mode in order to support tab completion.
+func (c *cmdShell) open(fs *flag.FlagSet) (readLine func() (string, error), restore func(), err error) {
+	fp, ok := c.in.(*os.File)
+	if !ok || !term.IsTerminal(int(fp.Fd())) {
+		c.out = fs.Output()
+		sc := bufio.NewScanner(c.in)
+		readLine = func() (string, error) {
+			if sc.Scan() {
+				return sc.Text(), nil
+			}
+			if err := sc.Err(); err != nil {
+				return "", err
+			}
+			return "", io.EOF
+		}
+		return readLine, func() {}, nil
+	}
+
+	state, err := term.MakeRaw(int(fp.Fd()))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rw := struct {
+		io.Reader
+		io.Writer
+	}{fp, fs.Output()}
+
+	c.term = term.NewTerminal(rw, fmt.Sprintf("%s> ", appLowerName))
+	c.term.AutoCompleteCallback = c.autoComplete
+	c.out = c.term
+
+	restore = func() {
+		term.Restore(int(fp.Fd()), state)
+	}
+
+	return c.term.ReadLine, restore, nil
+}
+
+// run executes a line of the session; quit is true when
+// the session must be closed.
+func (c *cmdShell) run(line string) (quit bool, err error) {
+	args, err := shellFields(line)
+	if err != nil || len(args) == 0 {
+		return false, err
+	}
+
+	name, args := args[0], args[1:]
+	switch name {
+	case "exit", "quit":
+		return true, nil
+	case "help":
+		c.help()
+		return false, nil
+	case "get":
+		return false, c.get(args)
+	case "put":
+		return false, c.put(args)
+	case "list":
+		return false, c.list(args)
+	case "delete":
+		return false, c.delete(args)
+	case "totp":
+		return false, c.totp(args)
+	case "search":
+		return false, c.search(args)
+	}
+
+	return false, fmt.Errorf("unknown command: %s (type 'help' for a list)", name)
+}
+
+func (c *cmdShell) help() {
+	names := make([]string, 0, len(shellCommands))
+	for name := range shellCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		el := shellCommands[name]
+		fmt.Fprintf(c.out, "  %-32s %s\n", el.usage, el.synopsis)
+	}
+}
+
+func (c *cmdShell) get(args []string) error {
+	if len(args) == 0 {
+		return c.usageError("get")
+	}
+
+	namespace := strcase.Kebab(args[0])
+	keys := make([]string, len(args)-1)
+	for i, el := range args[1:] {
+		keys[i] = strcase.Snake(el)
+	}
+
+	if len(keys) == 1 {
+		val, err := c.sto.GetOne(namespace, keys[0])
+		if err != nil {
+			return err
+		}
+		if err := recordAccess(c.sto, "get", namespace, keys[0]); err != nil {
+			return err
+		}
+		fmt.Fprintln(c.out, val)
+		return nil
+	}
+
+	all, err := c.sto.GetAll(namespace, keys...)
+	if err != nil {
+		return err
+	}
+
+	keys = make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if err := recordAccess(c.sto, "get", namespace, keys...); err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		fmt.Fprintf(c.out, "%s: %s\n", k, all[k])
+	}
+
+	return nil
+}
+
+func (c *cmdShell) put(args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return c.usageError("put")
+	}
+
+	namespace, key := strcase.Kebab(args[0]), strcase.Snake(args[1])
+
+	var val string
+	if len(args) == 3 {
+		val = args[2]
+	} else if c.term != nil {
+		var err error
+		val, err = c.term.ReadPassword("value: ")
+		if err != nil {
+			return err
+		}
+	}
+	if len(val) == 0 {
+		return fmt.Errorf("missing value")
+	}
+
+	if err := c.sto.PutOne(namespace, key, val); err != nil {
+		return err
+	}
+
+	if err := recordAccess(c.sto, "put", namespace, key); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "secret successfully stored (key: %s, namespace: %s)\n", key, namespace)
+
+	return nil
+}
+
+func (c *cmdShell) list(args []string) error {
+	if len(args) > 1 {
+		return c.usageError("list")
+	}
+
+	var all []string
+	var err error
+	if len(args) == 0 {
+		all, err = c.sto.Namespaces()
+	} else {
+		all, err = c.sto.Keys(strcase.Kebab(args[0]))
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, el := range all {
+		fmt.Fprintln(c.out, el)
+	}
+
+	return nil
+}
+
+func (c *cmdShell) delete(args []string) error {
+	yes := len(args) > 0 && (args[0] == "-y" || args[0] == "-yes")
+	if yes {
+		args = args[1:]
+	}
+	if len(args) == 0 || len(args) > 2 {
+		return c.usageError("delete")
+	}
+
+	namespace := strcase.Kebab(args[0])
+	keys, err := c.sto.Keys(namespace)
+	if err != nil {
+		return err
+	}
+
+	target := deleteTarget{namespace: namespace, count: len(keys)}
+	if len(args) == 2 {
+		target = deleteTarget{namespace: namespace, key: strcase.Snake(args[1])}
+		if !contains(keys, target.key) {
+			return kv.ErrKeyNotFound
+		}
+	}
+
+	if !yes {
+		if err := confirmDelete(c.out, []deleteTarget{target}, c.confirm); err != nil {
+			return err
+		}
+	}
+
+	if len(target.key) > 0 {
+		if err := c.sto.DeleteOne(namespace, target.key); err != nil {
+			return err
+		}
+		if err := recordAccess(c.sto, "delete", namespace, target.key); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "secret successfully deleted (key: %s, namespace: %s)\n", target.key, namespace)
+		return nil
+	}
+
+	if err := c.sto.DeleteAll(namespace); err != nil {
+		return err
+	}
+	if err := recordAccess(c.sto, "delete", namespace); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "namespace '%s' successfully deleted\n", namespace)
+
+	return nil
+}
+
+// confirm asks a yes/no question on the terminal of the session;
+// it fails with ErrNotTerminal when stdin is not a terminal.
+func (c *cmdShell) confirm(question string) (bool, error) {
+	if c.term == nil {
+		return false, ErrNotTerminal
+	}
+
+	c.term.SetPrompt(fmt.Sprintf("%s [y/N] ", question))
+	defer c.term.SetPrompt(fmt.Sprintf("%s> ", appLowerName))
+
+	ans, err := c.term.ReadLine()
+	if err != nil {
+		return false, err
+	}
+
+	ans = strings.ToLower(strings.TrimSpace(ans))
+	return ans == "y" || ans == "yes", nil
+}
+
+func (c *cmdShell) totp(args []string) error {
+	if len(args) != 1 {
+		return c.usageError("totp")
+	}
+
+	namespace := strcase.Kebab(args[0])
+	uri, err := c.sto.GetOne(namespace, "totp")
+	if err != nil {
+		return err
+	}
+	if len(uri) == 0 {
+		return fmt.Errorf("totp url not found in namespace: %s", namespace)
+	}
+
+	if err := recordAccess(c.sto, "totp", namespace, "totp"); err != nil {
+		return err
+	}
+
+	code, err := generateTotp(uri)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.out, code)
+
+	return nil
+}
+
+// search prints the 'namespace/key' references containing the text
+// (ignoring case); values are never searched.
+func (c *cmdShell) search(args []string) error {
+	if len(args) != 1 {
+		return c.usageError("search")
+	}
+
+	text := strings.ToLower(args[0])
+
+	namespaces, err := c.sto.Namespaces()
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		keys, err := c.sto.Keys(ns)
+		if err != nil {
+			return err
+		}
+
+		for _, k := range keys {
+			ref := ns + "/" + k
+			if strings.Contains(strings.ToLower(ref), text) {
+				fmt.Fprintln(c.out, ref)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *cmdShell) usageError(name string) error {
+	return fmt.Errorf("usage: %s", shellCommands[name].usage)
+}
+
+// autoComplete completes the command names, the namespaces and
+// the keys on TAB, up to the longest common prefix of the candidates.
+func (c *cmdShell) autoComplete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+
+	prefix := line[:pos]
+	args := strings.Fields(prefix)
+	word := ""
+	if len(args) > 0 && !strings.HasSuffix(prefix, " ") {
+		word, args = args[len(args)-1], args[:len(args)-1]
+	}
+
+	// the options of delete are not completed
+	if len(args) > 1 && args[0] == "delete" && (args[1] == "-y" || args[1] == "-yes") {
+		args = append(args[:1], args[2:]...)
+	}
+
+	var candidates []string
+	switch {
+	case len(args) == 0:
+		for name := range shellCommands {
+			candidates = append(candidates, name)
+		}
+	case len(args) == 1 && args[0] != "help" && args[0] != "exit" && args[0] != "search":
+		candidates, _ = c.sto.Namespaces()
+	case len(args) >= 2 && (args[0] == "get" || (len(args) == 2 && (args[0] == "put" || args[0] == "delete"))):
+		candidates, _ = c.sto.Keys(strcase.Kebab(args[1]))
+	}
+
+	var matches []string
+	for _, el := range candidates {
+		if strings.HasPrefix(el, word) {
+			matches = append(matches, el)
+		}
+	}
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+
+	completion := commonPrefix(matches)
+	if len(matches) == 1 {
+		completion += " "
+	}
+	if completion == word {
+		return "", 0, false
+	}
+
+	head := prefix[:len(prefix)-len(word)] + completion
+	return head + line[pos:], len(head), true
+}
+
+func commonPrefix(all []string) string {
+	res := all[0]
+	for _, el := range all[1:] {
+		for !strings.HasPrefix(el, res) {
+			res = res[:len(res)-1]
+		}
+	}
+	return res
+}
+
+func (c *cmdShell) complete(fs *flag.FlagSet) error {
+	pwd, err := getMasterSecret(&c.storeRef)
+	if err != nil {
+		return err
+	}
+	c.storeRef.MasterSecret = pwd
+
+	return nil
+}
+
+// shellFields splits a line into words, honoring single and double
+// quotes and backslash escapes (i.e. put web token "a b c").
+func shellFields(line string) ([]string, error) {
+	var res []string
+	var sb strings.Builder
+	var quote rune
+	inWord, escaped := false, false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			sb.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				sb.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				res = append(res, sb.String())
+				sb.Reset()
+				inWord = false
+			}
+		default:
+			sb.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape")
+	}
+	if inWord {
+		res = append(res, sb.String())
+	}
+
+	return res, nil
+}
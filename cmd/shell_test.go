@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCmdShell(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	script := strings.Join([]string{
+		`put stuffs "user name" pinco.pallo@gmail.com`,
+		`put stuffs password 'magick word'`,
+		`put google password abbracadabbra`,
+		`list`,
+		`get stuffs password`,
+		`search pass`,
+		`delete google`,
+		`get google password`,
+		`exit`,
+		`list`,
+	}, "\n")
+
+	out := bytes.NewBufferString("")
+	if err := runCmdShell(out, strings.NewReader(script)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"secret successfully stored (key: user_name, namespace: stuffs)",
+		"secret successfully stored (key: password, namespace: stuffs)",
+		"secret successfully stored (key: password, namespace: google)",
+		"google",
+		"stuffs",
+		"magick word",
+		"google/password",
+		"stuffs/password",
+		"namespace 'google' successfully deleted",
+		"error: namespace not found",
+	}
+
+	got := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if !cmp.Equal(want, got) {
+		t.Fatal(cmp.Diff(want, got))
+	}
+}
+
+func TestCmdShellIdleTimeout(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	// a reader that never returns a line
+	rd, wr := io.Pipe()
+	defer wr.Close()
+
+	out := bytes.NewBufferString("")
+	if err := runCmdShell(out, rd, "-timeout", "50ms"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := out.String(); !strings.HasPrefix(got, "store locked after") {
+		t.Fatalf("unexpected output: %s", got)
+	}
+}
+
+func TestCmdShellAutoComplete(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "user name", "pinco.pallo@gmail.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	op := newCmdShell()
+	op.storeRef.Set(testStore)
+	sto, err := op.storeRef.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sto.Close()
+	op.sto = sto
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"ge", "get "},
+		{"get st", "get stuffs "},
+		{"get stuffs u", "get stuffs user_name "},
+		{"totp stuffs u", ""},
+		{"se", "search "},
+		{"delete -y stuffs u", "delete -y stuffs user_name "},
+	}
+
+	for _, tc := range tests {
+		got, pos, ok := op.autoComplete(tc.line, len(tc.line), '\t')
+		if !ok {
+			got = ""
+		}
+		if got != tc.want || (ok && pos != len(got)) {
+			t.Fatalf("%q: expected: %q, got: %q (pos: %d)", tc.line, tc.want, got, pos)
+		}
+	}
+}
+
+func TestShellFields(t *testing.T) {
+	got, err := shellFields(`put "my ns" key 'it is' a\ b ""`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"put", "my ns", "key", "it is", "a b", ""}
+	if !cmp.Equal(want, got) {
+		t.Fatal(cmp.Diff(want, got))
+	}
+
+	if _, err := shellFields(`put "ns key`); err == nil {
+		t.Fatal("expected error for unterminated quote")
+	}
+}
+
+func TestCmdShellDelete(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	script := strings.Join([]string{
+		`put google user pinco.pallo`,
+		`put google password magick`,
+		`delete google token`,
+		`delete -y google user`,
+		`delete -y google`,
+		`list`,
+	}, "\n")
+
+	out := bytes.NewBufferString("")
+	if err := runCmdShell(out, strings.NewReader(script)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"secret successfully stored (key: user, namespace: google)",
+		"secret successfully stored (key: password, namespace: google)",
+		"error: key not found",
+		"secret successfully deleted (key: user, namespace: google)",
+		"namespace 'google' successfully deleted",
+	}
+
+	got := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if !cmp.Equal(want, got) {
+		t.Fatal(cmp.Diff(want, got))
+	}
+}
+
+func runCmdShell(output io.Writer, in io.Reader, extra ...string) error {
+	op := newCmdShell()
+	op.in = in
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	op.SetFlags(fs)
+
+	args := append([]string{"-s", testStore, "-timeout", "5s"}, extra...)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return op.Execute(fs)
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lucasepe/locker/cmd/flags"
+	"github.com/lucasepe/locker/internal/kv"
+	"github.com/lucasepe/locker/internal/merge"
+)
+
+func newCmdSync() *cmdSync {
+	return &cmdSync{
+		storeRef: newStoreFlag(),
+		resolve: flags.Enum{Choices: []string{
+			string(merge.KeepLocal),
+			string(merge.KeepRemote),
+			string(merge.KeepBoth),
+		}},
+	}
+}
+
+type cmdSync struct {
+	storeRef flags.Store
+	resolve  flags.Enum
+	remote   string
+	output   string
+}
+
+// syncResult is the structured output of sync.
+type syncResult struct {
+	// Status is 'merged' or, when some are left unresolved, 'conflicts'.
+	Status    string         `json:"status" yaml:"status"`
+	Pulled    int            `json:"pulled" yaml:"pulled"`
+	Pushed    int            `json:"pushed" yaml:"pushed"`
+	Conflicts []syncConflict `json:"conflicts,omitempty" yaml:"conflicts,omitempty"`
+}
+
+type syncConflict struct {
+	Secret string `json:"secret" yaml:"secret"`
+	Local  string `json:"local" yaml:"local"`
+	Remote string `json:"remote" yaml:"remote"`
+}
+
+func (*cmdSync) Name() string { return "sync" }
+func (*cmdSync) Synopsis() string {
+	return "Merge the secrets of two copies of a store."
+}
+
+func (*cmdSync) Usage() string {
+	return strings.ReplaceAll(`{NAME} sync [flags] <other.db>
+  
+   Merge the default store with a copy on a USB drive:
+     {NAME} sync /media/usb/locker.db
+
+   Merge the 'work' store solving conflicts in favor of the other copy:
+     {NAME} sync -s work -resolve keep-remote ~/backup/work.db`, "{NAME}", appLowerName)
+}
+
+func (c *cmdSync) SetFlags(fs *flag.FlagSet) {
+	fs.Var(&c.storeRef, "s", "Store name, alias or path.")
+	fs.Var(&c.resolve, "resolve", fmt.Sprintf("Conflicts resolution, one of: %s", strings.Join(c.resolve.Choices, ",")))
+}
+
+func (c *cmdSync) Execute(fs *flag.FlagSet) error {
+	if err := c.complete(fs); err != nil {
+		return err
+	}
+
+	local, err := c.storeRef.Connect()
+	if err != nil {
+		return err
+	}
+	defer local.Close()
+
+	if c.remote == c.storeRef.String() {
+		return fmt.Errorf("cannot sync a store with itself")
+	}
+
+	remote, err := c.storeRef.Open(c.remote)
+	if err != nil {
+		return err
+	}
+	defer remote.Close()
+
+	ls, ok := local.(kv.Syncer)
+	if !ok {
+		return fmt.Errorf("store does not support sync")
+	}
+	rs, ok := remote.(kv.Syncer)
+	if !ok {
+		return fmt.Errorf("store does not support sync")
+	}
+
+	base, err := lastSync(ls, rs)
+	if err != nil {
+		return err
+	}
+
+	le, err := ls.Entries()
+	if err != nil {
+		return err
+	}
+	re, err := rs.Entries()
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.remote, err)
+	}
+
+	now := time.Now()
+	res := merge.Merge(le, re, base, merge.Strategy(c.resolve.Value), now)
+	if err := ls.Apply(res.ToLocal...); err != nil {
+		return err
+	}
+	if err := rs.Apply(res.ToRemote...); err != nil {
+		return fmt.Errorf("%s: %w", c.remote, err)
+	}
+
+	if structured(c.output) {
+		out := syncResult{Status: "merged", Pulled: len(res.ToLocal), Pushed: len(res.ToRemote)}
+		for _, el := range res.Conflicts {
+			out.Status = "conflicts"
+			out.Conflicts = append(out.Conflicts, syncConflict{
+				Secret: describeEntry(el.Local),
+				Local:  describeChange(el.Local),
+				Remote: describeChange(el.Remote),
+			})
+		}
+		if err := encodeOutput(fs.Output(), c.output, out); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(fs.Output(), "stores merged (pulled: %d, pushed: %d)\n",
+			len(res.ToLocal), len(res.ToRemote))
+		for _, el := range res.Conflicts {
+			fmt.Fprintf(fs.Output(), "conflict: %s (local: %s, remote: %s)\n",
+				describeEntry(el.Local), describeChange(el.Local), describeChange(el.Remote))
+		}
+	}
+
+	if len(res.Conflicts) > 0 {
+		return fmt.Errorf("%d conflicts left unresolved, choose how to solve them with -resolve",
+			len(res.Conflicts))
+	}
+
+	if err := ls.SetLastSync(now); err != nil {
+		return err
+	}
+	return rs.SetLastSync(now)
+}
+
+func (c *cmdSync) complete(fs *flag.FlagSet) error {
+	c.output = globalOutput()
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("missing store to sync with")
+	}
+
+	var err error
+	c.remote, err = filepath.Abs(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	// opening it would create an empty store
+	if _, err := os.Stat(c.remote); err != nil {
+		return err
+	}
+
+	pwd, err := getMasterSecret(&c.storeRef)
+	if err != nil {
+		return err
+	}
+	c.storeRef.MasterSecret = pwd
+
+	return nil
+}
+
+// lastSync returns the earliest of the last sync times of both stores,
+// so that changes made after any of them are never silently dropped.
+func lastSync(a, b kv.Syncer) (time.Time, error) {
+	ta, err := a.LastSync()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	tb, err := b.LastSync()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if tb.Before(ta) {
+		return tb, nil
+	}
+	return ta, nil
+}
+
+func describeEntry(el kv.Entry) string {
+	return fmt.Sprintf("namespace: %s, key: %s", el.Namespace, el.Key)
+}
+
+func describeChange(el kv.Entry) string {
+	if el.Deleted {
+		return fmt.Sprintf("deleted at %s", el.Modified.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("modified at %s", el.Modified.Format(time.RFC3339))
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/lucasepe/locker/internal/kv"
+	"github.com/lucasepe/locker/internal/kv/bbolt"
+)
+
+func TestCmdSync(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	remote := filepath.Join(t.TempDir(), "remote.db")
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "user name", "pinco.pallo@gmail.com"); err != nil {
+		t.Fatal(err)
+	}
+	putRemote(t, remote, "password", "magick")
+
+	out.Reset()
+	if err := runCmdSync(out, remote, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := runCmdList(out); err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.Fields(out.String())
+	want := []string{"password", "user_name"}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("expected: %v, got: %v", want, got)
+	}
+
+	putRemote(t, remote, "password", "abbracadabbra")
+	if err := runCmdPut(out, "password", "open sesame"); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	err := runCmdSync(out, remote, "")
+	if err == nil || !strings.Contains(out.String(), "conflict: namespace: stuffs, key: password") {
+		t.Fatalf("expected a conflict, got: %v (%s)", err, out.String())
+	}
+
+	out.Reset()
+	if err := runCmdSync(out, remote, "keep-remote"); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := runCmdGet(out, "password"); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("abbracadabbra", out.String()); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestCmdSyncMissingRemote(t *testing.T) {
+	defer os.Remove(testArchivePath())
+
+	os.Setenv(EnvSecret, testSecret)
+
+	out := bytes.NewBufferString("")
+	if err := runCmdPut(out, "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	remote := filepath.Join(t.TempDir(), "typo.db")
+	if err := runCmdSync(out, remote, ""); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected: %v, got: %v", os.ErrNotExist, err)
+	}
+	if _, err := os.Stat(remote); !os.IsNotExist(err) {
+		t.Fatalf("expected no store created, got: %v", err)
+	}
+}
+
+func putRemote(t *testing.T, path, key, val string) {
+	sto, err := bbolt.NewStore(bbolt.Options{
+		Path:  path,
+		Codec: kv.NewCryptoCodec(testSecret),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sto.Close()
+
+	if err := sto.PutOne(testNamespace, key, val); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runCmdSync(output io.Writer, remote, resolve string) error {
+	op := newCmdSync()
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	op.SetFlags(fs)
+
+	args := []string{
+		"-s", testStore,
+	}
+	if len(resolve) > 0 {
+		args = append(args, "-resolve", resolve)
+	}
+	args = append(args, remote)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return op.Execute(fs)
+}
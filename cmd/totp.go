@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/lucasepe/locker/cmd/flags"
 	"github.com/lucasepe/totp"
@@ -12,15 +13,17 @@ import (
 func newCmdTotp() *cmdTotp {
 	return &cmdTotp{
 		namespace: flags.Namespace{},
-		storeRef: flags.Store{
-			BaseDir: AppDir(),
-		},
+		storeRef:  newStoreFlag(),
+		output:    flags.Enum{Choices: []string{fmtTxt, fmtJSON, fmtYAML}},
 	}
 }
 
 type cmdTotp struct {
-	namespace flags.Namespace
-	storeRef  flags.Store
+	namespace   flags.Namespace
+	storeRef    flags.Store
+	output      flags.Enum
+	clip        bool
+	clipTimeout time.Duration
 }
 
 func (*cmdTotp) Name() string { return "totp" }
@@ -32,12 +35,21 @@ func (*cmdTotp) Usage() string {
 	return strings.ReplaceAll(`{NAME} totp [flags]
   
    Generate a TOTP from a totp url stored into the 'google' namespace:
-     {NAME} totp -n google`, "{NAME}", appLowerName)
+     {NAME} totp -n google
+
+   Copy the TOTP to the clipboard:
+     {NAME} totp -n google -clip
+
+   Print the TOTP and its expiration time as JSON:
+     {NAME} totp -n google -o json`, "{NAME}", appLowerName)
 }
 
 func (c *cmdTotp) SetFlags(fs *flag.FlagSet) {
 	fs.Var(&c.namespace, "n", "Namespace.")
-	fs.Var(&c.storeRef, "s", "Store name.")
+	fs.Var(&c.storeRef, "s", "Store name, alias or path.")
+	fs.Var(&c.output, "o", fmt.Sprintf("Output format, one of: %s", strings.Join(c.output.Choices, ",")))
+	fs.BoolVar(&c.clip, "clip", false, "Copy the code to the clipboard instead of printing it.")
+	fs.DurationVar(&c.clipTimeout, "clip-timeout", clipboardTimeout(), "Clear the clipboard after this time (0 to disable).")
 }
 
 func (c *cmdTotp) Execute(fs *flag.FlagSet) error {
@@ -59,27 +71,58 @@ func (c *cmdTotp) Execute(fs *flag.FlagSet) error {
 		return fmt.Errorf("totp url not found in namespace: %s", c.namespace.String())
 	}
 
-	opts, err := totp.ParseURI(uri)
+	if err := recordAccess(sto, "totp", c.namespace.String(), "totp"); err != nil {
+		return err
+	}
+
+	code, err := generateTotp(uri)
 	if err != nil {
 		return err
 	}
 
-	code, err := totp.New(opts)
+	if structured(c.output.Value) {
+		expires, err := totpExpires(uri, time.Now())
+		if err != nil {
+			return err
+		}
+		return encodeOutput(fs.Output(), c.output.Value,
+			totpResult{Namespace: c.namespace.String(), Code: code, Expires: expires})
+	}
+
+	if !c.clip {
+		fmt.Fprint(fs.Output(), code)
+		return nil
+	}
+
+	clearIn, err := copyToClipboard(code, c.clipTimeout)
 	if err != nil {
 		return err
 	}
 
-	fmt.Fprint(fs.Output(), code)
+	fmt.Fprintf(fs.Output(), "code has been copied to the clipboard (namespace: %s)\n", c.namespace.String())
+	if clearIn > 0 {
+		fmt.Fprintf(fs.Output(), "the clipboard will be cleared in %s\n", clearIn)
+	}
 
-	return err
+	return nil
 }
 
 func (c *cmdTotp) complete(fs *flag.FlagSet) error {
+	// the default formats are not for the clipboard
+	if c.clip && c.output.Value == "" {
+		c.output.Set(fmtTxt)
+	}
+	resolveOutput(&c.output, fmtTxt)
+
 	if len(c.namespace.Bytes()) == 0 {
 		return fmt.Errorf("missing namespace")
 	}
 
-	pwd, err := getMasterSecret()
+	if c.clip && c.output.Value != fmtTxt {
+		return fmt.Errorf("only a code in txt format can be copied to the clipboard")
+	}
+
+	pwd, err := getMasterSecret(&c.storeRef)
 	if err != nil {
 		return err
 	}
@@ -87,3 +130,35 @@ func (c *cmdTotp) complete(fs *flag.FlagSet) error {
 
 	return nil
 }
+
+// generateTotp returns the current code for the specified TOTP url.
+func generateTotp(uri string) (string, error) {
+	opts, err := totp.ParseURI(uri)
+	if err != nil {
+		return "", err
+	}
+
+	return totp.New(opts)
+}
+
+// totpExpires returns when the current code for the specified TOTP url expires.
+func totpExpires(uri string, now time.Time) (time.Time, error) {
+	opts, err := totp.ParseURI(uri)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	period := opts.Period
+	if period <= 0 {
+		period = 30
+	}
+
+	return time.Unix((now.Unix()/period+1)*period, 0).UTC(), nil
+}
+
+// totpResult is the structured output of totp.
+type totpResult struct {
+	Namespace string    `json:"namespace" yaml:"namespace"`
+	Code      string    `json:"code" yaml:"code"`
+	Expires   time.Time `json:"expires" yaml:"expires"`
+}
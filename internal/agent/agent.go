@@ -0,0 +1,159 @@
+// Package agent keeps the master secret in memory and serves the stores
+// to the CLI over a Unix socket, like ssh-agent does with private keys.
+//
+// The agent never keeps a store open: each request opens the store file,
+// runs the operation and closes it, so the store can still be used
+// directly by other processes.
+package agent
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+	"sync"
+	"time"
+
+	"github.com/lucasepe/locker/internal/kv"
+	"github.com/lucasepe/locker/internal/kv/bbolt"
+)
+
+// EnvSock is the env var holding the path of the agent socket.
+const EnvSock = "LOCKER_AGENT_SOCK"
+
+// openTimeout is how long a request waits for the lock of a store file
+// held by another process, not to block the other requests.
+const openTimeout = 5 * time.Second
+
+// ErrLocked is returned by the requests served after the agent is locked.
+var ErrLocked = errors.New("agent is locked")
+
+// Options are the options of the agent.
+type Options struct {
+	// Secret is the master secret of the stores.
+	Secret string
+	// Idle is the time after the last request the agent is locked
+	// (zero disables it).
+	Idle time.Duration
+	// Lifetime is the time after the start the agent is locked,
+	// even if in use (zero disables it).
+	Lifetime time.Duration
+}
+
+// Agent serves the stores unlocked with the master secret.
+type Agent struct {
+	mu     sync.Mutex
+	codec  kv.Codec
+	idle   time.Duration
+	timers []*time.Timer
+	done   chan struct{}
+	once   sync.Once
+}
+
+// New creates an agent holding the master secret.
+func New(opts Options) *Agent {
+	a := &Agent{
+		codec: kv.NewCryptoCodec(opts.Secret),
+		idle:  opts.Idle,
+		done:  make(chan struct{}),
+	}
+
+	if opts.Idle > 0 {
+		a.timers = append(a.timers, time.AfterFunc(opts.Idle, a.Close))
+	}
+	if opts.Lifetime > 0 {
+		a.timers = append(a.timers, time.AfterFunc(opts.Lifetime, a.Close))
+	}
+
+	return a
+}
+
+// Serve accepts connections on the listener until the agent is locked
+// or one of its timeouts expires; the listener is closed on return.
+func (a *Agent) Serve(l net.Listener) error {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("Agent", &service{agent: a}); err != nil {
+		return err
+	}
+
+	go func() {
+		<-a.done
+		l.Close()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-a.done:
+				return nil
+			default:
+				return err
+			}
+		}
+
+		go func() {
+			srv.ServeConn(conn)
+			// 'lock' shuts down the agent once its client is gone,
+			// so that the reply is not lost
+			if a.locked() {
+				a.Close()
+			}
+		}()
+	}
+}
+
+// Done is closed when the agent stops serving.
+func (a *Agent) Done() <-chan struct{} {
+	return a.done
+}
+
+// Lock forgets the master secret; the agent stops serving
+// as soon as the current connections are closed.
+func (a *Agent) Lock() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.codec = nil
+}
+
+func (a *Agent) locked() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.codec == nil
+}
+
+// Close forgets the master secret and stops serving.
+func (a *Agent) Close() {
+	a.Lock()
+	a.once.Do(func() {
+		for _, t := range a.timers {
+			t.Stop()
+		}
+		close(a.done)
+	})
+}
+
+// with opens the store at path, runs fn and closes the store;
+// requests are served one at a time.
+func (a *Agent) with(path string, fn func(sto kv.Store) error) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.codec == nil {
+		return ErrLocked
+	}
+
+	if a.idle > 0 && len(a.timers) > 0 {
+		a.timers[0].Reset(a.idle)
+	}
+
+	sto, err := bbolt.NewStore(bbolt.Options{Path: path, Codec: a.codec, Timeout: openTimeout})
+	if err != nil {
+		return err
+	}
+
+	err = fn(sto)
+	if cerr := sto.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
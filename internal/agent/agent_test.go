@@ -0,0 +1,147 @@
+package agent
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lucasepe/locker/internal/audit"
+	"github.com/lucasepe/locker/internal/kv"
+)
+
+func TestAgent(t *testing.T) {
+	sock, path := tempPaths(t)
+
+	a := New(Options{Secret: "abbracadabbra"})
+	done := serve(t, a, sock)
+
+	sto, err := Dial(sock, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sto.PutOne("google", "password", "magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := sto.GetOne("google", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "magick" {
+		t.Fatalf("expected: magick, got: %s", got)
+	}
+
+	if _, err := sto.Keys("yahoo"); !errors.Is(err, kv.ErrNamespaceNotFound) {
+		t.Fatalf("expected: %v, got: %v", kv.ErrNamespaceNotFound, err)
+	}
+
+	log := audit.New(sto.(kv.Journal))
+	for i := 0; i < 3; i++ {
+		if err := log.Record("get", "google", "password"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, err := log.Verify(); err != nil || n != 3 {
+		t.Fatalf("expected 3 verified entries, got: %d (%v)", n, err)
+	}
+
+	// only the last record is sent to append a new one
+	var last []byte
+	err = sto.(kv.Journal).Records(func(rec []byte) error {
+		last = rec
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := sto.(kv.Journal).Last(); err != nil || !bytes.Equal(got, last) {
+		t.Fatalf("expected: %s, got: %s (%v)", last, got, err)
+	}
+
+	entries, err := sto.(kv.Syncer).Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Modified.IsZero() {
+		t.Fatalf("unexpected entries: %v", entries)
+	}
+	sto.Close()
+
+	if err := Lock(sock); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("agent still serving after lock")
+	}
+
+	if _, err := Dial(sock, path); err == nil {
+		t.Fatal("expected error connecting to a locked agent")
+	}
+}
+
+func TestAgentIdleTimeout(t *testing.T) {
+	sock, path := tempPaths(t)
+
+	a := New(Options{Secret: "abbracadabbra", Idle: 100 * time.Millisecond, Lifetime: time.Minute})
+	done := serve(t, a, sock)
+
+	sto, err := Dial(sock, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sto.Close()
+
+	// requests postpone the idle timeout
+	for i := 0; i < 4; i++ {
+		time.Sleep(50 * time.Millisecond)
+		if _, err := sto.Namespaces(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("agent still serving after the idle timeout")
+	}
+
+	if _, err := sto.Namespaces(); err == nil {
+		t.Fatal("expected error using an expired agent")
+	}
+}
+
+func tempPaths(t *testing.T) (sock, path string) {
+	// socket paths must be short (see sun_path)
+	dir, err := os.MkdirTemp("", "locker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	return filepath.Join(dir, "agent.sock"), filepath.Join(dir, "test.db")
+}
+
+func serve(t *testing.T, a *Agent, sock string) <-chan error {
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- a.Serve(l)
+	}()
+
+	return done
+}
@@ -0,0 +1,214 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"net/rpc"
+	"path/filepath"
+	"time"
+
+	"github.com/lucasepe/locker/internal/kv"
+	"github.com/lucasepe/locker/internal/secrets"
+	"go.etcd.io/bbolt"
+)
+
+// maxAppendRetries is the number of attempts to append a journal
+// record while other clients are appending too.
+const maxAppendRetries = 5
+
+// knownErrors are restored from the errors returned by the agent,
+// so that callers can still use errors.Is.
+var knownErrors = []error{
+	kv.ErrEmptyNamespace, kv.ErrEmptyKey, kv.ErrNamespaceNotFound, kv.ErrKeyNotFound,
+	kv.ErrReservedNamespace, kv.ErrNewerSchema, kv.ErrUnsetMasterPassword,
+	secrets.ErrDecryptFailed, bbolt.ErrBucketNotFound, ErrLocked, errJournalChanged,
+}
+
+var (
+	_ kv.Store   = (*client)(nil)
+	_ kv.Journal = (*client)(nil)
+	_ kv.Syncer  = (*client)(nil)
+)
+
+// Dial connects to the agent listening on the socket
+// and returns the store file at path served by it.
+func Dial(sock, path string) (kv.Store, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := rpc.Dial("unix", sock)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to the agent (%s: %s): %w", EnvSock, sock, err)
+	}
+
+	return &client{rpc: rc, path: path}, nil
+}
+
+// Lock makes the agent listening on the socket forget the master secret and exit.
+func Lock(sock string) error {
+	rc, err := rpc.Dial("unix", sock)
+	if err != nil {
+		return fmt.Errorf("unable to connect to the agent (%s: %s): %w", EnvSock, sock, err)
+	}
+	defer rc.Close()
+
+	return remoteError(rc.Call("Agent.Lock", &Request{}, &Response{}))
+}
+
+// client is a kv.Store served by the agent.
+type client struct {
+	rpc  *rpc.Client
+	path string
+}
+
+func (c *client) call(method string, req *Request) (*Response, error) {
+	req.Path = c.path
+
+	var res Response
+	if err := c.rpc.Call("Agent."+method, req, &res); err != nil {
+		return nil, remoteError(err)
+	}
+	return &res, nil
+}
+
+func (c *client) PutOne(namespace string, key, value string) error {
+	_, err := c.call("PutOne", &Request{Namespace: namespace, Key: key, Value: value})
+	return err
+}
+
+func (c *client) GetOne(namespace string, key string) (string, error) {
+	res, err := c.call("GetOne", &Request{Namespace: namespace, Key: key})
+	if err != nil {
+		return "", err
+	}
+	return res.Value, nil
+}
+
+func (c *client) GetAll(namespace string, keys ...string) (map[string]string, error) {
+	res, err := c.call("GetAll", &Request{Namespace: namespace, Keys: keys})
+	if err != nil {
+		return nil, err
+	}
+	if res.Values == nil {
+		res.Values = map[string]string{}
+	}
+	return res.Values, nil
+}
+
+func (c *client) DeleteOne(namespace string, key string) error {
+	_, err := c.call("DeleteOne", &Request{Namespace: namespace, Key: key})
+	return err
+}
+
+func (c *client) DeleteAll(namespace string) error {
+	_, err := c.call("DeleteAll", &Request{Namespace: namespace})
+	return err
+}
+
+func (c *client) Namespaces() ([]string, error) {
+	res, err := c.call("Namespaces", &Request{})
+	if err != nil {
+		return nil, err
+	}
+	return res.Names, nil
+}
+
+func (c *client) Keys(namespace string) ([]string, error) {
+	res, err := c.call("Keys", &Request{Namespace: namespace})
+	if err != nil {
+		return nil, err
+	}
+	return res.Names, nil
+}
+
+func (c *client) Close() error {
+	return c.rpc.Close()
+}
+
+// Append reads the last record and appends the new one in two requests;
+// it starts over if another client appended a record in between.
+func (c *client) Append(fn func(last []byte) ([]byte, error)) (err error) {
+	for i := 0; i < maxAppendRetries; i++ {
+		var last []byte
+		if last, err = c.Last(); err != nil {
+			return err
+		}
+
+		var rec []byte
+		if rec, err = fn(last); err != nil {
+			return err
+		}
+
+		_, err = c.call("Append", &Request{Last: last, Record: rec})
+		if !errors.Is(err, errJournalChanged) {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (c *client) Records(fn func(rec []byte) error) error {
+	res, err := c.call("Records", &Request{})
+	if err != nil {
+		return err
+	}
+
+	for _, rec := range res.Records {
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *client) Last() ([]byte, error) {
+	res, err := c.call("Last", &Request{})
+	if err != nil {
+		return nil, err
+	}
+	return res.Record, nil
+}
+
+func (c *client) Entries() ([]kv.Entry, error) {
+	res, err := c.call("Entries", &Request{})
+	if err != nil {
+		return nil, err
+	}
+	return res.Entries, nil
+}
+
+func (c *client) Apply(entries ...kv.Entry) error {
+	_, err := c.call("Apply", &Request{Entries: entries})
+	return err
+}
+
+func (c *client) LastSync() (time.Time, error) {
+	res, err := c.call("LastSync", &Request{})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return res.Time, nil
+}
+
+func (c *client) SetLastSync(t time.Time) error {
+	_, err := c.call("SetLastSync", &Request{Time: t})
+	return err
+}
+
+// remoteError restores the known errors from the messages sent by the agent.
+func remoteError(err error) error {
+	var se rpc.ServerError
+	if !errors.As(err, &se) {
+		return err
+	}
+
+	for _, el := range knownErrors {
+		if string(se) == el.Error() {
+			return el
+		}
+	}
+	return errors.New(string(se))
+}
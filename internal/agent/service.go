@@ -0,0 +1,204 @@
+package agent
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lucasepe/locker/internal/kv"
+)
+
+// errJournalChanged is returned when a journal record has been appended
+// between the read of the last record and the append of the new one.
+var errJournalChanged = errors.New("journal changed")
+
+// Request is the argument of the agent RPC methods.
+type Request struct {
+	// Path of the store file.
+	Path      string
+	Namespace string
+	Key       string
+	Keys      []string
+	Value     string
+	// Last is the last journal record known by the client.
+	Last []byte
+	// Record is the journal record to append.
+	Record  []byte
+	Entries []kv.Entry
+	Time    time.Time
+}
+
+// Response is the reply of the agent RPC methods.
+type Response struct {
+	Value   string
+	Values  map[string]string
+	Names   []string
+	Records [][]byte
+	// Record is the last journal record.
+	Record  []byte
+	Entries []kv.Entry
+	Time    time.Time
+}
+
+// service exposes the agent through net/rpc.
+type service struct {
+	agent *Agent
+}
+
+func (s *service) PutOne(req *Request, _ *Response) error {
+	return s.agent.with(req.Path, func(sto kv.Store) error {
+		return sto.PutOne(req.Namespace, req.Key, req.Value)
+	})
+}
+
+func (s *service) GetOne(req *Request, res *Response) error {
+	return s.agent.with(req.Path, func(sto kv.Store) (err error) {
+		res.Value, err = sto.GetOne(req.Namespace, req.Key)
+		return err
+	})
+}
+
+func (s *service) GetAll(req *Request, res *Response) error {
+	return s.agent.with(req.Path, func(sto kv.Store) (err error) {
+		res.Values, err = sto.GetAll(req.Namespace, req.Keys...)
+		return err
+	})
+}
+
+func (s *service) DeleteOne(req *Request, _ *Response) error {
+	return s.agent.with(req.Path, func(sto kv.Store) error {
+		return sto.DeleteOne(req.Namespace, req.Key)
+	})
+}
+
+func (s *service) DeleteAll(req *Request, _ *Response) error {
+	return s.agent.with(req.Path, func(sto kv.Store) error {
+		return sto.DeleteAll(req.Namespace)
+	})
+}
+
+func (s *service) Namespaces(req *Request, res *Response) error {
+	return s.agent.with(req.Path, func(sto kv.Store) (err error) {
+		res.Names, err = sto.Namespaces()
+		return err
+	})
+}
+
+func (s *service) Keys(req *Request, res *Response) error {
+	return s.agent.with(req.Path, func(sto kv.Store) (err error) {
+		res.Names, err = sto.Keys(req.Namespace)
+		return err
+	})
+}
+
+// Append appends the record only if the last record of the journal
+// is still the one seen by the client (see errJournalChanged).
+func (s *service) Append(req *Request, _ *Response) error {
+	return s.agent.with(req.Path, func(sto kv.Store) error {
+		j, err := journal(sto)
+		if err != nil {
+			return err
+		}
+
+		return j.Append(func(last []byte) ([]byte, error) {
+			if !bytes.Equal(last, req.Last) {
+				return nil, errJournalChanged
+			}
+			return req.Record, nil
+		})
+	})
+}
+
+func (s *service) Records(req *Request, res *Response) error {
+	return s.agent.with(req.Path, func(sto kv.Store) error {
+		j, err := journal(sto)
+		if err != nil {
+			return err
+		}
+
+		return j.Records(func(rec []byte) error {
+			res.Records = append(res.Records, rec)
+			return nil
+		})
+	})
+}
+
+func (s *service) Last(req *Request, res *Response) error {
+	return s.agent.with(req.Path, func(sto kv.Store) error {
+		j, err := journal(sto)
+		if err != nil {
+			return err
+		}
+
+		res.Record, err = j.Last()
+		return err
+	})
+}
+
+func (s *service) Entries(req *Request, res *Response) error {
+	return s.agent.with(req.Path, func(sto kv.Store) error {
+		syn, err := syncer(sto)
+		if err != nil {
+			return err
+		}
+
+		res.Entries, err = syn.Entries()
+		return err
+	})
+}
+
+func (s *service) Apply(req *Request, _ *Response) error {
+	return s.agent.with(req.Path, func(sto kv.Store) error {
+		syn, err := syncer(sto)
+		if err != nil {
+			return err
+		}
+
+		return syn.Apply(req.Entries...)
+	})
+}
+
+func (s *service) LastSync(req *Request, res *Response) error {
+	return s.agent.with(req.Path, func(sto kv.Store) error {
+		syn, err := syncer(sto)
+		if err != nil {
+			return err
+		}
+
+		res.Time, err = syn.LastSync()
+		return err
+	})
+}
+
+func (s *service) SetLastSync(req *Request, _ *Response) error {
+	return s.agent.with(req.Path, func(sto kv.Store) error {
+		syn, err := syncer(sto)
+		if err != nil {
+			return err
+		}
+
+		return syn.SetLastSync(req.Time)
+	})
+}
+
+func (s *service) Lock(_ *Request, _ *Response) error {
+	s.agent.Lock()
+	return nil
+}
+
+func journal(sto kv.Store) (kv.Journal, error) {
+	j, ok := sto.(kv.Journal)
+	if !ok {
+		return nil, fmt.Errorf("store does not support journals")
+	}
+	return j, nil
+}
+
+func syncer(sto kv.Store) (kv.Syncer, error) {
+	syn, ok := sto.(kv.Syncer)
+	if !ok {
+		return nil, fmt.Errorf("store does not support sync")
+	}
+	return syn, nil
+}
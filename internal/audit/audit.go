@@ -0,0 +1,163 @@
+// Package audit provides a tamper-evident log of secret accesses.
+//
+// Every entry records who did what on which namespace/key and when,
+// never the secret value. Entries are hash-chained: each one embeds the
+// hash of the previous entry, so any change to the history is detected
+// by Verify.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/lucasepe/locker/internal/kv"
+)
+
+// ErrTampered is returned by Verify when the log chain is broken.
+var ErrTampered = errors.New("audit log has been tampered with")
+
+// Entry is a single audit log record.
+type Entry struct {
+	Seq       uint64    `json:"seq" yaml:"seq"`
+	Time      time.Time `json:"time" yaml:"time"`
+	User      string    `json:"user" yaml:"user"`
+	Operation string    `json:"op" yaml:"op"`
+	Namespace string    `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Keys      []string  `json:"keys,omitempty" yaml:"keys,omitempty"`
+	Prev      string    `json:"prev" yaml:"prev"`
+	Hash      string    `json:"hash" yaml:"hash"`
+}
+
+// digest computes the entry hash over all fields but Hash.
+func (e Entry) digest() (string, error) {
+	e.Hash = ""
+	dat, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(dat)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Log is an audit log stored in a kv.Journal.
+type Log struct {
+	journal kv.Journal
+	now     func() time.Time
+	user    string
+}
+
+// New returns an audit log backed by the specified journal.
+func New(j kv.Journal) *Log {
+	return &Log{
+		journal: j,
+		now:     time.Now,
+		user:    currentUser(),
+	}
+}
+
+// Record appends an entry for the operation on the namespace keys.
+func (l *Log) Record(op, namespace string, keys ...string) error {
+	return l.journal.Append(func(last []byte) ([]byte, error) {
+		el := Entry{
+			Seq:       1,
+			Time:      l.now().UTC().Round(0),
+			User:      l.user,
+			Operation: op,
+			Namespace: namespace,
+			Keys:      keys,
+		}
+
+		if last != nil {
+			var prev Entry
+			if err := json.Unmarshal(last, &prev); err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrTampered, err)
+			}
+			el.Seq = prev.Seq + 1
+			el.Prev = prev.Hash
+		}
+
+		var err error
+		el.Hash, err = el.digest()
+		if err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(el)
+	})
+}
+
+// Entries calls fn for each entry, oldest first.
+func (l *Log) Entries(fn func(Entry) error) error {
+	return l.journal.Records(func(rec []byte) error {
+		var el Entry
+		if err := json.Unmarshal(rec, &el); err != nil {
+			return fmt.Errorf("%w: %s", ErrTampered, err)
+		}
+		return fn(el)
+	})
+}
+
+// Verify walks the whole chain and returns the number of entries checked.
+// It fails with ErrTampered at the first entry whose sequence number,
+// hash or link to the previous entry does not match, or if the chain
+// does not end with the last entry recorded.
+func (l *Log) Verify() (int, error) {
+	var prev Entry
+	count := 0
+	err := l.Entries(func(el Entry) error {
+		if el.Seq != prev.Seq+1 {
+			return fmt.Errorf("%w: entry %d follows entry %d", ErrTampered, el.Seq, prev.Seq)
+		}
+
+		if el.Prev != prev.Hash {
+			return fmt.Errorf("%w: entry %d is not linked to entry %d", ErrTampered, el.Seq, prev.Seq)
+		}
+
+		sum, err := el.digest()
+		if err != nil {
+			return err
+		}
+		if sum != el.Hash {
+			return fmt.Errorf("%w: entry %d hash mismatch", ErrTampered, el.Seq)
+		}
+
+		prev = el
+		count = count + 1
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	// the newest entries may have been removed
+	rec, err := l.journal.Last()
+	if err != nil {
+		return count, err
+	}
+	var last Entry
+	if rec != nil {
+		if err := json.Unmarshal(rec, &last); err != nil {
+			return count, fmt.Errorf("%w: %s", ErrTampered, err)
+		}
+	}
+	if last.Seq != prev.Seq || last.Hash != prev.Hash {
+		return count, fmt.Errorf("%w: the entries after entry %d are missing", ErrTampered, prev.Seq)
+	}
+
+	return count, nil
+}
+
+func currentUser() string {
+	if usr, err := user.Current(); err == nil {
+		return usr.Username
+	}
+
+	return os.Getenv("USER")
+}
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLogVerify(t *testing.T) {
+	log := newTestLog()
+
+	if err := log.Record("put", "google", "password"); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Record("get", "google", "password", "user"); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Record("delete", "google"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := log.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 3 {
+		t.Fatalf("expected: 3, got: %d", got)
+	}
+}
+
+func TestLogVerifyDetectsTampering(t *testing.T) {
+	tests := map[string]func(j *memJournal){
+		"edited": func(j *memJournal) {
+			var el Entry
+			json.Unmarshal(j.recs[1], &el)
+			el.Namespace = "yahoo"
+			j.recs[1], _ = json.Marshal(el)
+		},
+		"removed": func(j *memJournal) {
+			j.recs = append(j.recs[:1], j.recs[2:]...)
+		},
+		"swapped": func(j *memJournal) {
+			j.recs[0], j.recs[1] = j.recs[1], j.recs[0]
+		},
+		"truncated": func(j *memJournal) {
+			j.recs = j.recs[:2]
+		},
+		"emptied": func(j *memJournal) {
+			j.recs = nil
+		},
+	}
+
+	for name, tamper := range tests {
+		t.Run(name, func(t *testing.T) {
+			log := newTestLog()
+			for _, op := range []string{"put", "get", "totp"} {
+				if err := log.Record(op, "google", "password"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tamper(log.journal.(*memJournal))
+
+			_, err := log.Verify()
+			if !errors.Is(err, ErrTampered) {
+				t.Fatalf("expected: %v, got: %v", ErrTampered, err)
+			}
+		})
+	}
+}
+
+func newTestLog() *Log {
+	log := New(&memJournal{})
+	log.user = "pinco"
+	log.now = func() time.Time {
+		return time.Date(2023, 3, 14, 9, 26, 53, 0, time.UTC)
+	}
+	return log
+}
+
+type memJournal struct {
+	recs [][]byte
+	last []byte
+}
+
+func (j *memJournal) Append(fn func(last []byte) ([]byte, error)) error {
+	rec, err := fn(j.last)
+	if err != nil {
+		return err
+	}
+	j.recs = append(j.recs, rec)
+	j.last = rec
+	return nil
+}
+
+func (j *memJournal) Last() ([]byte, error) {
+	return j.last, nil
+}
+
+func (j *memJournal) Records(fn func(rec []byte) error) error {
+	for _, rec := range j.recs {
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
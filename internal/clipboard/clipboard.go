@@ -1,26 +1,144 @@
+// Package clipboard copies text to the system clipboard using the tool
+// available on the platform (pbcopy, wl-copy, xclip, xsel, clip) or,
+// when none is available, the OSC 52 terminal escape sequence.
 package clipboard
 
-import "os/exec"
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
 
-const (
-	copyCmd = "pbcopy"
+var (
+	ErrUnavailable = errors.New("no clipboard available (install wl-clipboard, xclip or xsel)")
+	ErrNotReadable = errors.New("clipboard cannot be read")
 )
 
-func Write(text string) error {
-	cmd := exec.Command(copyCmd)
-	in, err := cmd.StdinPipe()
-	if err != nil {
-		return err
+// for testing
+var (
+	goos     = runtime.GOOS
+	getenv   = os.Getenv
+	lookPath = exec.LookPath
+)
+
+// Provider copies to and pastes from a clipboard.
+type Provider struct {
+	// Name of the clipboard tool (or 'osc52').
+	Name  string
+	copy  []string
+	paste []string
+}
+
+// providers are the clipboard tools, in order of preference.
+var providers = []struct {
+	Provider
+	// available reports whether the provider can be used.
+	available func() bool
+}{
+	{
+		Provider:  Provider{Name: "pbcopy", copy: []string{"pbcopy"}, paste: []string{"pbpaste"}},
+		available: func() bool { return goos == "darwin" },
+	},
+	{
+		Provider: Provider{Name: "clip", copy: []string{"clip"},
+			paste: []string{"powershell", "-NoProfile", "-Command", "Get-Clipboard"}},
+		available: func() bool { return goos == "windows" },
+	},
+	{
+		Provider:  Provider{Name: "wl-copy", copy: []string{"wl-copy"}, paste: []string{"wl-paste", "-n"}},
+		available: func() bool { return len(getenv("WAYLAND_DISPLAY")) > 0 },
+	},
+	{
+		Provider: Provider{Name: "xclip", copy: []string{"xclip", "-selection", "clipboard"},
+			paste: []string{"xclip", "-selection", "clipboard", "-o"}},
+		available: func() bool { return len(getenv("DISPLAY")) > 0 },
+	},
+	{
+		Provider: Provider{Name: "xsel", copy: []string{"xsel", "--clipboard", "--input"},
+			paste: []string{"xsel", "--clipboard", "--output"}},
+		available: func() bool { return len(getenv("DISPLAY")) > 0 },
+	},
+}
+
+// Detect returns the clipboard of the current session.
+func Detect() (*Provider, error) {
+	for _, el := range providers {
+		if !el.available() {
+			continue
+		}
+		if _, err := lookPath(el.copy[0]); err == nil {
+			p := el.Provider
+			return &p, nil
+		}
 	}
 
-	if err := cmd.Start(); err != nil {
-		return err
+	if osc52Available() {
+		return &Provider{Name: osc52Name}, nil
 	}
-	if _, err := in.Write([]byte(text)); err != nil {
-		return err
+
+	return nil, ErrUnavailable
+}
+
+// Write copies the text to the clipboard.
+func (p *Provider) Write(text string) error {
+	if p.Name == osc52Name {
+		return osc52Write(text)
+	}
+
+	cmd := exec.Command(p.copy[0], p.copy[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
+
+// Read returns the content of the clipboard; it fails with
+// ErrNotReadable when the clipboard is write only (i.e. OSC 52).
+func (p *Provider) Read() (string, error) {
+	if len(p.paste) == 0 {
+		return "", ErrNotReadable
+	}
+
+	var out bytes.Buffer
+	cmd := exec.Command(p.paste[0], p.paste[1:]...)
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	res := out.String()
+	if p.Name == "clip" {
+		res = strings.TrimSuffix(res, "\r\n")
+	}
+	return res, nil
+}
+
+// Readable reports whether the content of the clipboard can be read.
+func (p *Provider) Readable() bool {
+	return len(p.paste) > 0
+}
+
+// ClearIf empties the clipboard, but only if it still holds a content
+// for which match returns true; it reports whether it has been cleared.
+func (p *Provider) ClearIf(match func(content string) bool) (bool, error) {
+	cur, err := p.Read()
+	if err != nil {
+		return false, err
 	}
-	if err := in.Close(); err != nil {
+
+	if !match(cur) {
+		return false, nil
+	}
+
+	return true, p.Write("")
+}
+
+// Write copies the text to the clipboard of the current session.
+func Write(text string) error {
+	p, err := Detect()
+	if err != nil {
 		return err
 	}
-	return cmd.Wait()
+	return p.Write(text)
 }
@@ -0,0 +1,150 @@
+package clipboard
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		goos      string
+		env       map[string]string
+		installed []string
+		tty       bool
+		want      string
+	}{
+		{goos: "darwin", installed: []string{"pbcopy"}, want: "pbcopy"},
+		{goos: "windows", installed: []string{"clip"}, want: "clip"},
+		{
+			goos:      "linux",
+			env:       map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"},
+			installed: []string{"wl-copy", "xclip"},
+			want:      "wl-copy",
+		},
+		{
+			goos:      "linux",
+			env:       map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"},
+			installed: []string{"xclip"},
+			want:      "xclip",
+		},
+		{
+			goos:      "linux",
+			env:       map[string]string{"DISPLAY": ":0"},
+			installed: []string{"xsel"},
+			want:      "xsel",
+		},
+		{
+			goos:      "linux",
+			env:       map[string]string{"SSH_TTY": "/dev/pts/0"},
+			installed: []string{"xclip"},
+			tty:       true,
+			want:      "osc52",
+		},
+		{goos: "linux", installed: []string{"xclip"}},
+	}
+
+	origGoos, origGetenv, origLookPath, origTTYPath := goos, getenv, lookPath, ttyPath
+	defer func() {
+		goos, getenv, lookPath, ttyPath = origGoos, origGetenv, origLookPath, origTTYPath
+	}()
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			goos = tc.goos
+			getenv = func(name string) string { return tc.env[name] }
+			lookPath = func(file string) (string, error) {
+				for _, el := range tc.installed {
+					if el == file {
+						return "/usr/bin/" + file, nil
+					}
+				}
+				return "", fmt.Errorf("%s: not found", file)
+			}
+
+			ttyPath = filepath.Join(t.TempDir(), "missing")
+			if tc.tty {
+				ttyPath = filepath.Join(t.TempDir(), "tty")
+				os.WriteFile(ttyPath, nil, 0600)
+			}
+
+			p, err := Detect()
+			if len(tc.want) == 0 {
+				if err != ErrUnavailable {
+					t.Fatalf("expected: %v, got: %v", ErrUnavailable, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Name != tc.want {
+				t.Fatalf("expected: %s, got: %s", tc.want, p.Name)
+			}
+		})
+	}
+}
+
+func TestOSC52(t *testing.T) {
+	origGetenv, origTTYPath := getenv, ttyPath
+	defer func() { getenv, ttyPath = origGetenv, origTTYPath }()
+
+	getenv = func(string) string { return "" }
+	ttyPath = filepath.Join(t.TempDir(), "tty")
+
+	if err := os.WriteFile(ttyPath, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Provider{Name: osc52Name}
+	if err := p.Write("magick"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(ttyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "\x1b]52;c;bWFnaWNr\a"; string(got) != want {
+		t.Fatalf("expected: %q, got: %q", want, got)
+	}
+
+	if _, err := p.Read(); err != ErrNotReadable {
+		t.Fatalf("expected: %v, got: %v", ErrNotReadable, err)
+	}
+
+	want := "\x1bPtmux;\x1b\x1b]52;c;bWFnaWNr\a\x1b\\"
+	if got := osc52Sequence("magick", true); got != want {
+		t.Fatalf("expected: %q, got: %q", want, got)
+	}
+}
+
+func TestClearIf(t *testing.T) {
+	// a fake clipboard tool saving the content to a file
+	dir := t.TempDir()
+	data := filepath.Join(dir, "clipboard")
+	copyTool := filepath.Join(dir, "copy")
+	pasteTool := filepath.Join(dir, "paste")
+	os.WriteFile(copyTool, []byte("#!/bin/sh\ncat > "+data+"\n"), 0700)
+	os.WriteFile(pasteTool, []byte("#!/bin/sh\ncat "+data+"\n"), 0700)
+
+	p := &Provider{Name: "fake", copy: []string{copyTool}, paste: []string{pasteTool}}
+	if err := p.Write("magick"); err != nil {
+		t.Skip(err)
+	}
+
+	ok, err := p.ClearIf(func(s string) bool { return s == "other" })
+	if err != nil || ok {
+		t.Fatalf("expected not cleared, got: %v (%v)", ok, err)
+	}
+
+	ok, err = p.ClearIf(func(s string) bool { return s == "magick" })
+	if err != nil || !ok {
+		t.Fatalf("expected cleared, got: %v (%v)", ok, err)
+	}
+
+	if got, _ := p.Read(); got != "" {
+		t.Fatalf("expected empty clipboard, got: %s", got)
+	}
+}
@@ -0,0 +1,45 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"os"
+	"strings"
+)
+
+// osc52Name is the name of the provider writing the OSC 52 escape
+// sequence to the terminal, supported by most terminal emulators
+// (also through SSH sessions).
+const osc52Name = "osc52"
+
+// for testing
+var ttyPath = "/dev/tty"
+
+func osc52Available() bool {
+	tty, err := os.OpenFile(ttyPath, os.O_WRONLY, 0)
+	if err != nil {
+		return false
+	}
+	tty.Close()
+	return true
+}
+
+func osc52Write(text string) error {
+	tty, err := os.OpenFile(ttyPath, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer tty.Close()
+
+	_, err = tty.WriteString(osc52Sequence(text, len(getenv("TMUX")) > 0))
+	return err
+}
+
+// osc52Sequence returns the escape sequence setting the clipboard;
+// within tmux it is wrapped in a passthrough sequence.
+func osc52Sequence(text string, tmux bool) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if tmux {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return seq
+}
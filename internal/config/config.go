@@ -0,0 +1,103 @@
+// Package config reads the configuration file holding the defaults
+// of the command line flags.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the configuration file.
+const FileName = "config.yaml"
+
+// Config holds the defaults; the zero value means not set.
+type Config struct {
+	// Store is the default store (name or alias).
+	Store string `yaml:"store"`
+	// Output is the default output format.
+	Output string `yaml:"output"`
+	// ClipboardTimeout is the time after which the clipboard is cleared.
+	ClipboardTimeout *time.Duration `yaml:"clipboard_timeout"`
+	// LockTimeout is the idle time after which the shell and the agent lock the stores.
+	LockTimeout *time.Duration `yaml:"lock_timeout"`
+	// Stores holds the settings of the stores by name (or alias).
+	Stores map[string]Store `yaml:"stores"`
+}
+
+// Store holds the settings of a store.
+type Store struct {
+	// Path of the store file, when outside the application directory
+	// (i.e. on a USB drive); the store name is an alias for it.
+	Path string `yaml:"path"`
+	// Secret is where the master secret is read from:
+	// env:NAME, keyring:USER, file:PATH or cmd:COMMAND.
+	Secret string `yaml:"secret"`
+}
+
+// Load reads the configuration file; a missing file is an empty configuration.
+// Paths starting with '~' are relative to the home directory, the other
+// relative paths are relative to the directory of the configuration file.
+func Load(path string) (*Config, error) {
+	dat, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Config{}
+
+	dec := yaml.NewDecoder(bytes.NewReader(dat))
+	dec.KnownFields(true)
+	if err := dec.Decode(res); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for name, el := range res.Stores {
+		if len(el.Path) == 0 {
+			continue
+		}
+		if el.Path, err = expand(filepath.Dir(path), el.Path); err != nil {
+			return nil, fmt.Errorf("%s: store %s: %w", path, name, err)
+		}
+		res.Stores[name] = el
+	}
+
+	return res, nil
+}
+
+// Aliases returns the paths of the stores with a path, by name.
+func (c *Config) Aliases() map[string]string {
+	res := map[string]string{}
+	for name, el := range c.Stores {
+		if len(el.Path) > 0 {
+			res[name] = el.Path
+		}
+	}
+	return res
+}
+
+func expand(dir, path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, path[1:]), nil
+	}
+
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path), nil
+	}
+
+	return filepath.Join(dir, path), nil
+}
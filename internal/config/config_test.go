@@ -0,0 +1,81 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, FileName)
+
+	src := `store: work
+output: env
+clipboard_timeout: 10s
+lock_timeout: 0s
+stores:
+  work:
+    secret: env:WORK_SECRET
+  usb:
+    path: /media/usb/locker.db
+    secret: keyring:usb
+  rel:
+    path: stores/rel.db
+`
+	if err := os.WriteFile(name, []byte(src), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Load(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clip, lock := 10*time.Second, time.Duration(0)
+	want := &Config{
+		Store:            "work",
+		Output:           "env",
+		ClipboardTimeout: &clip,
+		LockTimeout:      &lock,
+		Stores: map[string]Store{
+			"work": {Secret: "env:WORK_SECRET"},
+			"usb":  {Path: filepath.Clean("/media/usb/locker.db"), Secret: "keyring:usb"},
+			"rel":  {Path: filepath.Join(dir, "stores", "rel.db")},
+		},
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatal(cmp.Diff(want, got))
+	}
+
+	wantAliases := map[string]string{
+		"usb": filepath.Clean("/media/usb/locker.db"),
+		"rel": filepath.Join(dir, "stores", "rel.db"),
+	}
+	if !cmp.Equal(wantAliases, got.Aliases()) {
+		t.Fatal(cmp.Diff(wantAliases, got.Aliases()))
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	got, err := Load(filepath.Join(t.TempDir(), FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(&Config{}, got) {
+		t.Fatalf("expected empty config, got: %+v", got)
+	}
+
+	name := filepath.Join(t.TempDir(), FileName)
+	for _, src := range []string{"stor: work\n", "clipboard_timeout: soon\n"} {
+		if err := os.WriteFile(name, []byte(src), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(name); err == nil {
+			t.Fatalf("expected error for: %s", src)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// Package envvar builds environment variable names from secret keys.
+package envvar
+
+import (
+	"strings"
+)
+
+// Name returns a valid environment variable name made of the prefix
+// followed by the key: letters are uppercased and any character
+// other than letters, digits and underscores is replaced by an underscore.
+func Name(prefix, key string) string {
+	var sb strings.Builder
+	for _, r := range prefix + key {
+		switch {
+		case r >= 'a' && r <= 'z':
+			sb.WriteRune(r - 'a' + 'A')
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte('_')
+		}
+	}
+
+	res := sb.String()
+	if len(res) == 0 || (res[0] >= '0' && res[0] <= '9') {
+		res = "_" + res
+	}
+
+	return res
+}
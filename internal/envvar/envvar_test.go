@@ -0,0 +1,104 @@
+package envvar
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		prefix string
+		key    string
+		want   string
+	}{
+		{"", "password", "PASSWORD"},
+		{"DB_", "user_name", "DB_USER_NAME"},
+		{"", "api-key", "API_KEY"},
+		{"", "2fa code", "_2FA_CODE"},
+		{"aws.", "secret", "AWS_SECRET"},
+		{"", "città", "CITT_"},
+	}
+
+	for _, tc := range tests {
+		got := Name(tc.prefix, tc.key)
+		if got != tc.want {
+			t.Fatalf("expected: %s, got: %s", tc.want, got)
+		}
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"PASSWORD", true},
+		{"_2FA", true},
+		{"db_user", true},
+		{"2FA", false},
+		{"API-KEY", false},
+		{"", false},
+	}
+
+	for _, tc := range tests {
+		if got := Valid(tc.name); got != tc.want {
+			t.Fatalf("%s: expected: %t, got: %t", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestAssign(t *testing.T) {
+	tests := []struct {
+		format string
+		val    string
+		want   string
+	}{
+		{Dotenv, "s3cr3t", `V='s3cr3t'`},
+		{Dotenv, `a "b" $c`, `V='a "b" $c'`},
+		{Dotenv, "it's\nme", `V="it's\nme"`},
+		{Dotenv, `C:\ "x" $y'`, `V="C:\\ \"x\" \$y'"`},
+		{Sh, "it's me", `export V='it'\''s me'`},
+		{Sh, "a\nb $c", "export V='a\nb $c'"},
+		{Fish, `it's C:\`, `set -gx V 'it\'s C:\\'`},
+		{Docker, `a "b" 'c'`, `V=a "b" 'c'`},
+	}
+
+	for _, tc := range tests {
+		got, err := Assign(tc.format, "V", tc.val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Fatalf("%s: expected: %s, got: %s", tc.format, tc.want, got)
+		}
+	}
+
+	if _, err := Assign(Docker, "V", "a\nb"); err == nil {
+		t.Fatal("expected error for multi-line docker value")
+	}
+
+	for _, format := range Formats {
+		if _, err := Assign(format, "V; id", "s3cr3t"); err == nil {
+			t.Fatalf("%s: expected error for an invalid name", format)
+		}
+	}
+}
+
+func TestAssignShRoundTrip(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+
+	for _, val := range []string{"plain", "it's me", "a\nb", `$HOME "q" \n`, "`id`"} {
+		line, _ := Assign(Sh, "V", val)
+
+		out, err := exec.Command(sh, "-c", line+`; printf %s "$V"`).Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != val {
+			t.Fatalf("expected: %q, got: %q", val, out)
+		}
+	}
+}
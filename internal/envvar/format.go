@@ -0,0 +1,86 @@
+package envvar
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Formats of the variable assignments.
+const (
+	// Dotenv is a line of a .env file (i.e. NAME='value').
+	Dotenv = "dotenv"
+	// Sh is a POSIX shell export (i.e. export NAME='value').
+	Sh = "sh"
+	// Fish is a fish shell export (i.e. set -gx NAME 'value').
+	Fish = "fish"
+	// Docker is a line of a docker --env-file (i.e. NAME=value).
+	Docker = "docker"
+)
+
+// Formats are all the supported formats.
+var Formats = []string{Dotenv, Sh, Fish, Docker}
+
+// Valid reports whether the name is a valid environment variable name.
+func Valid(name string) bool {
+	if len(name) == 0 || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// Assign returns the assignment of the value to the variable in the format,
+// quoting the value so that it is read back unchanged.
+// The name must be a valid variable name, it is never quoted.
+func Assign(format, name, val string) (string, error) {
+	if !Valid(name) {
+		return "", fmt.Errorf("variable %s: not a valid name", name)
+	}
+
+	switch format {
+	case Dotenv:
+		return name + "=" + dotenvQuote(val), nil
+	case Sh:
+		return "export " + name + "=" + shQuote(val), nil
+	case Fish:
+		return "set -gx " + name + " " + fishQuote(val), nil
+	case Docker:
+		// the env-file values are taken literally, up to the end of the line
+		if strings.ContainsAny(val, "\r\n") {
+			return "", fmt.Errorf("variable %s: multi-line values are not supported by docker env files", name)
+		}
+		return name + "=" + val, nil
+	}
+
+	return "", fmt.Errorf("unsupported format: %s", format)
+}
+
+// dotenvQuote single quotes the value (taken literally by the dotenv parsers)
+// or, when it holds single quotes or newlines, double quotes and escapes it.
+func dotenvQuote(val string) string {
+	if !strings.ContainsAny(val, "'\r\n") {
+		return "'" + val + "'"
+	}
+
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`, "\r", `\r`)
+	return `"` + r.Replace(val) + `"`
+}
+
+// shQuote single quotes the value, closing and reopening the quotes around each escaped single quote.
+func shQuote(val string) string {
+	return "'" + strings.ReplaceAll(val, "'", `'\''`) + "'"
+}
+
+// fishQuote single quotes the value; in fish only \ and ' are escaped.
+func fishQuote(val string) string {
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + r.Replace(val) + "'"
+}
@@ -0,0 +1,99 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Bitwarden imports unencrypted Bitwarden JSON exports;
+// each item becomes a namespace.
+type Bitwarden struct{}
+
+func (Bitwarden) Detect(_ string, head []byte) bool {
+	head = bytes.TrimSpace(head)
+	return bytes.HasPrefix(head, []byte("{")) &&
+		bytes.Contains(head, []byte(`"items"`)) &&
+		(bytes.Contains(head, []byte(`"encrypted"`)) || bytes.Contains(head, []byte(`"folders"`)))
+}
+
+func (Bitwarden) Import(path string) ([]Record, error) {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc bitwardenExport
+	if err := json.Unmarshal(dat, &doc); err != nil {
+		return nil, err
+	}
+
+	if doc.Encrypted {
+		return nil, errors.New("encrypted bitwarden exports are not supported")
+	}
+
+	var res []Record
+	for _, it := range doc.Items {
+		c := collector{namespace: it.Name}
+		if it.Login != nil {
+			c.add("username", it.Login.Username)
+			c.add("password", it.Login.Password)
+			c.add("totp", it.Login.Totp)
+			for i, u := range it.Login.Uris {
+				key := "uri"
+				if i > 0 {
+					key = fmt.Sprintf("uri_%d", i+1)
+				}
+				c.add(key, u.URI)
+			}
+		}
+		if it.Card != nil {
+			c.add("cardholder_name", it.Card.CardholderName)
+			c.add("brand", it.Card.Brand)
+			c.add("number", it.Card.Number)
+			c.add("exp_month", it.Card.ExpMonth)
+			c.add("exp_year", it.Card.ExpYear)
+			c.add("code", it.Card.Code)
+		}
+		for _, f := range it.Fields {
+			c.add(f.Name, f.Value)
+		}
+		c.add("notes", it.Notes)
+
+		res = append(res, c.res...)
+	}
+
+	return res, nil
+}
+
+type bitwardenExport struct {
+	Encrypted bool            `json:"encrypted"`
+	Items     []bitwardenItem `json:"items"`
+}
+
+type bitwardenItem struct {
+	Name  string `json:"name"`
+	Notes string `json:"notes"`
+	Login *struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Totp     string `json:"totp"`
+		Uris     []struct {
+			URI string `json:"uri"`
+		} `json:"uris"`
+	} `json:"login"`
+	Card *struct {
+		CardholderName string `json:"cardholderName"`
+		Brand          string `json:"brand"`
+		Number         string `json:"number"`
+		ExpMonth       string `json:"expMonth"`
+		ExpYear        string `json:"expYear"`
+		Code           string `json:"code"`
+	} `json:"card"`
+	Fields []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"fields"`
+}
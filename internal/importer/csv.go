@@ -0,0 +1,161 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// CSV imports comma separated exports with a header row;
+// each row becomes a namespace named after the title column.
+type CSV struct {
+	// Signatures lists sets of header columns identifying the format,
+	// any of them is enough.
+	Signatures [][]string
+	// Title lists the candidate namespace columns.
+	Title []string
+	// Columns maps the exported columns onto locker keys;
+	// columns not listed here are ignored.
+	Columns map[string]string
+}
+
+var (
+	// KeePassCSV imports KeePass (1.x and 2.x) and KeePassXC CSV exports.
+	KeePassCSV = CSV{
+		Signatures: [][]string{
+			{"account", "login name", "password"},
+			{"group", "title", "username", "password"},
+		},
+		Title: []string{"title", "account"},
+		Columns: map[string]string{
+			"username":   "username",
+			"login name": "username",
+			"password":   "password",
+			"url":        "url",
+			"web site":   "url",
+			"notes":      "notes",
+			"comments":   "notes",
+			"totp":       "totp",
+		},
+	}
+
+	// OnePasswordCSV imports 1Password CSV exports.
+	OnePasswordCSV = CSV{
+		Signatures: [][]string{
+			{"title", "username", "password", "otpauth"},
+			{"title", "url", "username", "password"},
+		},
+		Title: []string{"title"},
+		Columns: map[string]string{
+			"username": "username",
+			"password": "password",
+			"url":      "url",
+			"otpauth":  "totp",
+			"notes":    "notes",
+		},
+	}
+)
+
+func (f CSV) Detect(path string, head []byte) bool {
+	if !strings.EqualFold(fileExt(path), ".csv") {
+		return false
+	}
+
+	line, _, _ := bytes.Cut(head, []byte("\n"))
+	cols, err := csv.NewReader(bytes.NewReader(line)).Read()
+	if err != nil {
+		return false
+	}
+
+	return f.match(normalizeHeader(cols))
+}
+
+func (f CSV) Import(path string) ([]Record, error) {
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+
+	rdr := csv.NewReader(fp)
+	rdr.FieldsPerRecord = -1
+
+	header, err := rdr.Read()
+	if err != nil {
+		return nil, err
+	}
+	header = normalizeHeader(header)
+
+	title := -1
+	for i, col := range header {
+		if contains(f.Title, col) {
+			title = i
+			break
+		}
+	}
+	if title < 0 {
+		return nil, fmt.Errorf("missing title column, expected one of: %s",
+			strings.Join(f.Title, ","))
+	}
+
+	var res []Record
+	for {
+		row, err := rdr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if title >= len(row) || len(strings.TrimSpace(row[title])) == 0 {
+			continue
+		}
+
+		c := collector{namespace: strings.TrimSpace(row[title])}
+		for i, col := range header {
+			key, ok := f.Columns[col]
+			if ok && i < len(row) {
+				c.add(key, row[i])
+			}
+		}
+		res = append(res, c.res...)
+	}
+
+	return res, nil
+}
+
+func (f CSV) match(header []string) bool {
+	for _, sig := range f.Signatures {
+		found := true
+		for _, col := range sig {
+			if !contains(header, col) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeHeader(cols []string) []string {
+	res := make([]string, len(cols))
+	for i, col := range cols {
+		res[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(col, "\ufeff")))
+	}
+	return res
+}
+
+func contains(s []string, e string) bool {
+	for _, v := range s {
+		if v == e {
+			return true
+		}
+	}
+	return false
+}
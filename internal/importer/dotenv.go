@@ -0,0 +1,119 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Dotenv imports KEY=value files; the records have no namespace.
+type Dotenv struct{}
+
+var dotenvLine = regexp.MustCompile(`^\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_.-]*)\s*=`)
+
+func (Dotenv) Detect(path string, head []byte) bool {
+	name := filepath.Base(path)
+	if name == ".env" || strings.HasPrefix(name, ".env.") || fileExt(name) == ".env" {
+		return true
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(head))
+	found := false
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !dotenvLine.MatchString(line) {
+			return false
+		}
+		found = true
+	}
+
+	return found
+}
+
+func (Dotenv) Import(path string) ([]Record, error) {
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+
+	var res []Record
+	sc := bufio.NewScanner(fp)
+	num := 0
+	for sc.Scan() {
+		num = num + 1
+		line := strings.TrimSpace(sc.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		m := dotenvLine.FindStringSubmatchIndex(line)
+		if m == nil {
+			return nil, fmt.Errorf("line %d: invalid syntax", num)
+		}
+
+		key := line[m[2]:m[3]]
+		val, err := dotenvValue(strings.TrimSpace(line[m[1]:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+
+		res = append(res, Record{Key: key, Value: val})
+	}
+
+	return res, sc.Err()
+}
+
+// dotenvValue unquotes a value: single quoted values are literal,
+// double quoted ones support the usual escape sequences,
+// unquoted ones end at the first inline comment.
+func dotenvValue(s string) (string, error) {
+	if len(s) == 0 {
+		return s, nil
+	}
+
+	switch q := s[0]; q {
+	case '\'':
+		end := strings.IndexByte(s[1:], q)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quoted value")
+		}
+		return s[1 : end+1], nil
+	case '"':
+		var sb strings.Builder
+		for i := 1; i < len(s); i++ {
+			switch ch := s[i]; {
+			case ch == '"':
+				return sb.String(), nil
+			case ch == '\\' && i+1 < len(s):
+				i = i + 1
+				switch s[i] {
+				case 'n':
+					sb.WriteByte('\n')
+				case 'r':
+					sb.WriteByte('\r')
+				case 't':
+					sb.WriteByte('\t')
+				default:
+					sb.WriteByte(s[i])
+				}
+			default:
+				sb.WriteByte(ch)
+			}
+		}
+		return "", fmt.Errorf("unterminated quoted value")
+	}
+
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = s[:i]
+	}
+
+	return strings.TrimSpace(s), nil
+}
@@ -0,0 +1,84 @@
+// Package importer reads secrets exported by other password managers
+// and tools, mapping their entries onto namespaces and keys.
+package importer
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Record is a single imported secret.
+//
+// An empty Namespace means that the format has no notion of namespaces
+// (i.e. dotenv files); the caller chooses one.
+type Record struct {
+	Namespace string
+	Key       string
+	Value     string
+}
+
+// Importer reads secrets from a specific format.
+type Importer interface {
+	// Detect reports whether the file at path looks like this format;
+	// head holds the first bytes of the file (nil for directories).
+	Detect(path string, head []byte) bool
+	// Import reads all the secrets from the file (or directory) at path.
+	Import(path string) ([]Record, error)
+}
+
+// Head returns the first bytes of the file at path,
+// or nil if path is a directory.
+func Head(path string) ([]byte, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return nil, nil
+	}
+
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+
+	buf := make([]byte, 4096)
+	n, err := io.ReadFull(fp, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+
+	return buf[:n], nil
+}
+
+// collector accumulates the non blank fields of an entry,
+// keeping the values unchanged.
+type collector struct {
+	namespace string
+	res       []Record
+}
+
+func (c *collector) add(key, val string) {
+	if len(key) == 0 || len(strings.TrimSpace(val)) == 0 {
+		return
+	}
+
+	c.res = append(c.res, Record{Namespace: c.namespace, Key: key, Value: val})
+}
+
+func fileExt(path string) string {
+	return strings.ToLower(filepath.Ext(path))
+}
+
+func sortedKeys(m map[string]string) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
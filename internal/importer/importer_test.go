@@ -0,0 +1,163 @@
+package importer
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestImporters(t *testing.T) {
+	tests := []struct {
+		file string
+		imp  Importer
+		want []Record
+	}{
+		{
+			file: "bitwarden.json",
+			imp:  Bitwarden{},
+			want: []Record{
+				{"Google Login", "username", "pinco.pallo@gmail.com"},
+				{"Google Login", "password", "abbracadabbra123"},
+				{"Google Login", "totp", "otpauth://totp/Acme?secret=IRXW4J3UEBKGK3DMEBAW46KPNZSSC"},
+				{"Google Login", "uri", "https://accounts.google.com"},
+				{"Google Login", "PIN", "1234"},
+				{"Google Login", "notes", "personal account"},
+				{"Visa", "cardholder_name", "Pinco Pallo"},
+				{"Visa", "brand", "Visa"},
+				{"Visa", "number", "4111111111111111"},
+				{"Visa", "exp_month", "12"},
+				{"Visa", "exp_year", "2030"},
+				{"Visa", "code", "123"},
+			},
+		},
+		{
+			file: "keepass.xml",
+			imp:  KeePassXML{},
+			want: []Record{
+				{"Google Login", "username", "pinco.pallo@gmail.com"},
+				{"Google Login", "password", "abbracadabbra123"},
+				{"Google Login", "url", "https://accounts.google.com"},
+				{"Yahoo Login", "username", "pino.lalavatrice@yahoo.com"},
+				{"Yahoo Login", "password", "tu dimmi"},
+				{"Yahoo Login", "Security Question", "pizza"},
+			},
+		},
+		{
+			file: "keepass.csv",
+			imp:  KeePassCSV,
+			want: []Record{
+				{"Google Login", "username", "pinco.pallo@gmail.com"},
+				{"Google Login", "password", "abbracadabbra123"},
+				{"Google Login", "url", "https://accounts.google.com"},
+				{"Yahoo Login", "username", "pino.lalavatrice@yahoo.com"},
+				{"Yahoo Login", "password", "tu dimmi"},
+				{"Yahoo Login", "notes", "a, b and c"},
+			},
+		},
+		{
+			file: "1password.csv",
+			imp:  OnePasswordCSV,
+			want: []Record{
+				{"Google Login", "url", "https://accounts.google.com"},
+				{"Google Login", "username", "pinco.pallo@gmail.com"},
+				{"Google Login", "password", "abbracadabbra123"},
+				{"Google Login", "totp", "otpauth://totp/Acme?secret=IRXW4J3UEBKGK3DMEBAW46KPNZSSC"},
+				{"Yahoo Login", "url", "https://login.yahoo.com"},
+				{"Yahoo Login", "username", "pino.lalavatrice@yahoo.com"},
+				{"Yahoo Login", "password", "tu dimmi"},
+			},
+		},
+		{
+			file: "pass",
+			imp:  Pass{},
+			want: []Record{
+				{"email/gmail", "password", "abbracadabbra123"},
+				{"email/gmail", "login", "pinco.pallo@gmail.com"},
+				{"email/gmail", "url", "https://accounts.google.com"},
+				{"email/gmail", "notes", "recovery codes follow\n1234 5678"},
+				{"yahoo", "password", "tu dimmi"},
+			},
+		},
+		{
+			file: "app.env",
+			imp:  Dotenv{},
+			want: []Record{
+				{"", "DB_HOST", "localhost"},
+				{"", "DB_USER", "pinco pallo"},
+				{"", "DB_PASSWORD", "s3cr3t #1"},
+				{"", "GREETING", "hello\nworld"},
+			},
+		},
+		{
+			file: "secret.yaml",
+			imp:  KubeSecret{},
+			want: []Record{
+				{"app-creds", "password", "abbracadabbra123"},
+				// values are imported unchanged
+				{"app-creds", "token", " s3cr3t \n"},
+				{"app-creds", "username", "pinco.pallo"},
+				{"app-creds", "url", "https://accounts.google.com"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.file, func(t *testing.T) {
+			path := filepath.Join("testdata", tc.file)
+			got, err := tc.imp.Import(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !cmp.Equal(tc.want, got) {
+				t.Fatal(cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	importers := []struct {
+		name string
+		imp  Importer
+	}{
+		{"bitwarden", Bitwarden{}},
+		{"keepass-xml", KeePassXML{}},
+		{"keepass-csv", KeePassCSV},
+		{"1password", OnePasswordCSV},
+		{"pass", Pass{}},
+		{"dotenv", Dotenv{}},
+		{"k8s", KubeSecret{}},
+	}
+
+	tests := map[string]string{
+		"bitwarden.json": "bitwarden",
+		"keepass.xml":    "keepass-xml",
+		"keepass.csv":    "keepass-csv",
+		"1password.csv":  "1password",
+		"pass":           "pass",
+		"app.env":        "dotenv",
+		"secret.yaml":    "k8s",
+	}
+
+	for file, want := range tests {
+		path := filepath.Join("testdata", file)
+		head, err := Head(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := ""
+		for _, el := range importers {
+			if el.imp.Detect(path, head) {
+				got = el.name
+				break
+			}
+		}
+
+		if got != want {
+			t.Fatalf("%s: expected: %s, got: %s", file, want, got)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"strings"
+)
+
+// KeePassXML imports KeePass 2.x XML exports;
+// each entry becomes a namespace named after its title.
+type KeePassXML struct{}
+
+func (KeePassXML) Detect(_ string, head []byte) bool {
+	return bytes.Contains(head, []byte("<KeePassFile"))
+}
+
+func (KeePassXML) Import(path string) ([]Record, error) {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Groups []keepassGroup `xml:"Root>Group"`
+	}
+	if err := xml.Unmarshal(dat, &doc); err != nil {
+		return nil, err
+	}
+
+	var res []Record
+	for _, g := range doc.Groups {
+		res = append(res, g.records()...)
+	}
+
+	return res, nil
+}
+
+type keepassGroup struct {
+	Entries []keepassEntry `xml:"Entry"`
+	Groups  []keepassGroup `xml:"Group"`
+}
+
+type keepassEntry struct {
+	Strings []struct {
+		Key   string `xml:"Key"`
+		Value string `xml:"Value"`
+	} `xml:"String"`
+}
+
+// keepassKeys maps the KeePass standard fields onto locker keys.
+var keepassKeys = map[string]string{
+	"UserName": "username",
+	"Password": "password",
+	"URL":      "url",
+	"Notes":    "notes",
+	"otp":      "totp",
+}
+
+func (g keepassGroup) records() []Record {
+	var res []Record
+	for _, e := range g.Entries {
+		c := collector{}
+		for _, s := range e.Strings {
+			if s.Key == "Title" {
+				c.namespace = strings.TrimSpace(s.Value)
+			}
+		}
+		if len(c.namespace) == 0 {
+			continue
+		}
+
+		for _, s := range e.Strings {
+			if s.Key == "Title" {
+				continue
+			}
+			key, ok := keepassKeys[s.Key]
+			if !ok {
+				key = s.Key
+			}
+			c.add(key, s.Value)
+		}
+
+		res = append(res, c.res...)
+	}
+
+	for _, sub := range g.Groups {
+		res = append(res, sub.records()...)
+	}
+
+	return res
+}
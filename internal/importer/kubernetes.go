@@ -0,0 +1,87 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KubeSecret imports Kubernetes Secret manifests (also wrapped in a List);
+// each secret becomes a namespace named after it.
+type KubeSecret struct{}
+
+var kubeSecretKind = regexp.MustCompile(`(?m)^\s*kind:\s*["']?Secret["']?\s*$`)
+
+func (KubeSecret) Detect(_ string, head []byte) bool {
+	return bytes.Contains(head, []byte("apiVersion:")) && kubeSecretKind.Match(head)
+}
+
+func (KubeSecret) Import(path string) ([]Record, error) {
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+
+	var res []Record
+	dec := yaml.NewDecoder(fp)
+	for {
+		var doc kubeObject
+		if err := dec.Decode(&doc); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		items := []kubeObject{doc}
+		if doc.Kind == "List" {
+			items = doc.Items
+		}
+
+		for _, it := range items {
+			recs, err := it.records()
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, recs...)
+		}
+	}
+
+	return res, nil
+}
+
+type kubeObject struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+	Data       map[string]string `yaml:"data"`
+	StringData map[string]string `yaml:"stringData"`
+	Items      []kubeObject      `yaml:"items"`
+}
+
+func (o kubeObject) records() ([]Record, error) {
+	if o.Kind != "Secret" {
+		return nil, nil
+	}
+
+	c := collector{namespace: o.Metadata.Name}
+	for _, k := range sortedKeys(o.Data) {
+		val, err := base64.StdEncoding.DecodeString(o.Data[k])
+		if err != nil {
+			return nil, fmt.Errorf("secret %s: key %s: %w", o.Metadata.Name, k, err)
+		}
+		c.add(k, string(val))
+	}
+	for _, k := range sortedKeys(o.StringData) {
+		c.add(k, o.StringData[k])
+	}
+
+	return c.res, nil
+}
@@ -0,0 +1,81 @@
+package importer
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Pass imports a pass-style directory tree of plaintext files.
+//
+// Each file becomes a namespace named after its path: the first line is
+// the password, the following 'key: value' lines are secrets on their own
+// and anything else is kept as notes.
+type Pass struct{}
+
+func (Pass) Detect(_ string, head []byte) bool {
+	return head == nil
+}
+
+func (Pass) Import(root string) ([]Record, error) {
+	var res []Record
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(d.Name(), ".") && path != root {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		if fileExt(path) == ".gpg" || fileExt(path) == ".age" {
+			return errors.New("encrypted pass entries are not supported, decrypt them first")
+		}
+
+		dat, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = strings.TrimSuffix(rel, fileExt(rel))
+
+		res = append(res, parsePassEntry(filepath.ToSlash(rel), string(dat))...)
+		return nil
+	})
+
+	return res, err
+}
+
+func parsePassEntry(namespace, content string) []Record {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	c := collector{namespace: namespace}
+	c.add("password", lines[0])
+
+	var notes []string
+	for _, line := range lines[1:] {
+		key, val, ok := strings.Cut(line, ":")
+		if ok && len(key) > 0 && !strings.ContainsAny(key, " \t") && !strings.HasPrefix(val, "//") {
+			// the blanks around the value are part of the 'key: value' syntax
+			c.add(key, strings.TrimSpace(val))
+			continue
+		}
+		notes = append(notes, line)
+	}
+	c.add("notes", strings.TrimRight(strings.Join(notes, "\n"), "\n"))
+
+	return c.res
+}
@@ -1,6 +1,9 @@
 package bbolt
 
 import (
+	"errors"
+	"time"
+
 	"github.com/lucasepe/locker/internal/kv"
 	"go.etcd.io/bbolt"
 )
@@ -12,20 +15,54 @@ type Options struct {
 	Path string
 	// Encoding format.
 	Codec kv.Codec
+	// ReadOnly opens the DB file in read-only mode,
+	// without upgrading its schema.
+	ReadOnly bool
+	// Timeout is how long to wait for the lock of the DB file
+	// held by another process (zero waits forever);
+	// then kv.ErrStoreInUse is returned.
+	Timeout time.Duration
 }
 
 // NewStore creates a new bbolt store.
+// Stores written by an older build are upgraded to the current schema version
+// (unless opened read-only);
+// stores written by a newer build are opened but refuse any write.
 // You must call the Close() method on the store when you're done working with it.
 func NewStore(options Options) (kv.Store, error) {
 	// Open DB
-	db, err := bbolt.Open(options.Path, 0600, nil)
+	db, err := bbolt.Open(options.Path, 0600, &bbolt.Options{
+		ReadOnly: options.ReadOnly,
+		Timeout:  options.Timeout,
+		// the freelist is loaded anyway by the writable stores,
+		// the read-only ones need it for the stats
+		PreLoadFreelist: options.ReadOnly,
+	})
+	if errors.Is(err, bbolt.ErrTimeout) {
+		return nil, kv.ErrStoreInUse
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	var ver int
+	if options.ReadOnly {
+		err = db.View(func(tx *bbolt.Tx) (err error) {
+			ver, err = readSchemaVersion(tx)
+			return err
+		})
+	} else {
+		ver, err = migrate(db)
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	return &boltStore{
-		db:    db,
-		codec: options.Codec,
+		db:       db,
+		codec:    options.Codec,
+		readOnly: ver > SchemaVersion(),
 	}, nil
 }
 
@@ -33,19 +70,24 @@ var _ kv.Store = (*boltStore)(nil)
 
 // boltStore is a kv.Store implementation for bbolt (formerly known as Bolt / Bolt DB).
 type boltStore struct {
-	db    *bbolt.DB
-	codec kv.Codec
+	db       *bbolt.DB
+	codec    kv.Codec
+	readOnly bool
 }
 
 func (s *boltStore) PutOne(namespace string, key, value string) error {
-	if len(namespace) == 0 {
-		return kv.ErrEmptyNamespace
+	if err := s.checkWrite(namespace); err != nil {
+		return err
 	}
 
 	if len(key) == 0 {
 		return kv.ErrEmptyKey
 	}
 
+	if s.codec == nil {
+		return kv.ErrUnsetMasterPassword
+	}
+
 	data, err := s.codec.Marshal([]byte(value))
 	if err != nil {
 		return err
@@ -56,13 +98,16 @@ func (s *boltStore) PutOne(namespace string, key, value string) error {
 		if err != nil {
 			return err
 		}
-		return bkt.Put([]byte(key), data)
+		if err := bkt.Put([]byte(key), data); err != nil {
+			return err
+		}
+		return touch(tx, namespace, key, time.Now(), false)
 	})
 }
 
 func (s *boltStore) GetOne(namespace, key string) (value string, err error) {
-	if len(namespace) == 0 {
-		return "", kv.ErrEmptyNamespace
+	if err := checkNamespace(namespace); err != nil {
+		return "", err
 	}
 
 	if len(key) == 0 {
@@ -97,8 +142,8 @@ func (s *boltStore) GetOne(namespace, key string) (value string, err error) {
 }
 
 func (s *boltStore) DeleteOne(namespace, key string) error {
-	if len(namespace) == 0 {
-		return kv.ErrEmptyNamespace
+	if err := s.checkWrite(namespace); err != nil {
+		return err
 	}
 
 	if len(key) == 0 {
@@ -110,22 +155,44 @@ func (s *boltStore) DeleteOne(namespace, key string) error {
 		if bkt == nil {
 			return kv.ErrNamespaceNotFound
 		}
-		return bkt.Delete([]byte(key))
+		if bkt.Get([]byte(key)) == nil {
+			return kv.ErrKeyNotFound
+		}
+		if err := bkt.Delete([]byte(key)); err != nil {
+			return err
+		}
+		return touch(tx, namespace, key, time.Now(), true)
 	})
 }
 
 func (s *boltStore) DeleteAll(namespace string) error {
-	if len(namespace) == 0 {
-		return kv.ErrEmptyNamespace
+	if err := s.checkWrite(namespace); err != nil {
+		return err
 	}
 
 	return s.db.Update(func(tx *bbolt.Tx) error {
+		bkt := tx.Bucket([]byte(namespace))
+		if bkt == nil {
+			return kv.ErrNamespaceNotFound
+		}
+
+		now := time.Now()
+		err := bkt.ForEach(func(k, _ []byte) error {
+			return touch(tx, namespace, string(k), now, true)
+		})
+		if err != nil {
+			return err
+		}
+
 		return tx.DeleteBucket([]byte(namespace))
 	})
 }
 
 func (s *boltStore) GetAll(namespace string, keys ...string) (map[string]string, error) {
 	res := make(map[string]string)
+	if err := checkNamespace(namespace); err != nil {
+		return res, err
+	}
 
 	err := s.db.View(func(tx *bbolt.Tx) error {
 		bkt := tx.Bucket([]byte(namespace))
@@ -166,6 +233,9 @@ func (s *boltStore) GetAll(namespace string, keys ...string) (map[string]string,
 func (s *boltStore) Namespaces() (names []string, err error) {
 	err = s.db.View(func(tx *bbolt.Tx) error {
 		return tx.ForEach(func(bn []byte, _ *bbolt.Bucket) error {
+			if isReserved(string(bn)) {
+				return nil
+			}
 			el := make([]byte, len(bn))
 			copy(el, bn)
 			names = append(names, string(el))
@@ -178,6 +248,10 @@ func (s *boltStore) Namespaces() (names []string, err error) {
 
 // Keys returns all keys in a namespace.
 func (s *boltStore) Keys(namespace string) (items []string, err error) {
+	if err := checkNamespace(namespace); err != nil {
+		return items, err
+	}
+
 	return items, s.db.View(func(tx *bbolt.Tx) error {
 		bkt := tx.Bucket([]byte(namespace))
 		if bkt == nil {
@@ -203,6 +277,27 @@ func (s *boltStore) Close() error {
 	return s.db.Close()
 }
 
+// checkWrite reports whether the namespace can be modified.
+func (s *boltStore) checkWrite(namespace string) error {
+	if s.db.IsReadOnly() {
+		return bbolt.ErrDatabaseReadOnly
+	}
+	if s.readOnly {
+		return kv.ErrNewerSchema
+	}
+	return checkNamespace(namespace)
+}
+
+func checkNamespace(namespace string) error {
+	if len(namespace) == 0 {
+		return kv.ErrEmptyNamespace
+	}
+	if isReserved(namespace) {
+		return kv.ErrReservedNamespace
+	}
+	return nil
+}
+
 func contains(s []string, e string) bool {
 	for _, v := range s {
 		if v == e {
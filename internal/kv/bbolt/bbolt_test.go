@@ -0,0 +1,177 @@
+package bbolt
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lucasepe/locker/internal/kv"
+	"go.etcd.io/bbolt"
+)
+
+func TestDeleteNotFound(t *testing.T) {
+	path := tempfile()
+	defer os.Remove(path)
+
+	sto, err := NewStore(Options{Path: path, Codec: kv.NewCryptoCodec("HELLO!")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sto.Close()
+
+	if err := sto.PutOne("google", "user", "pinco.pallo"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sto.DeleteOne("google", "password"); !errors.Is(err, kv.ErrKeyNotFound) {
+		t.Fatalf("expected: %v, got: %v", kv.ErrKeyNotFound, err)
+	}
+	if err := sto.DeleteOne("yahoo", "user"); !errors.Is(err, kv.ErrNamespaceNotFound) {
+		t.Fatalf("expected: %v, got: %v", kv.ErrNamespaceNotFound, err)
+	}
+	if err := sto.DeleteAll("yahoo"); !errors.Is(err, kv.ErrNamespaceNotFound) {
+		t.Fatalf("expected: %v, got: %v", kv.ErrNamespaceNotFound, err)
+	}
+
+	// no tombstone is left for the missing key
+	entries, err := sto.(kv.Syncer).Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Key != "user" {
+		t.Fatalf("unexpected entries: %v", entries)
+	}
+}
+
+func TestEntriesWithoutNamespace(t *testing.T) {
+	path := tempfile()
+	defer os.Remove(path)
+
+	sto, err := NewStore(Options{Path: path, Codec: kv.NewCryptoCodec("HELLO!")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sto.Close()
+
+	if err := sto.PutOne("google", "user", "pinco.pallo"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the namespace is gone, its entries are not
+	err = sto.(*boltStore).db.Update(func(tx *bbolt.Tx) error {
+		return tx.DeleteBucket([]byte("google"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := sto.(kv.Syncer).Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no entries, got: %v", entries)
+	}
+}
+
+func TestJournalTruncated(t *testing.T) {
+	path := tempfile()
+	defer os.Remove(path)
+
+	sto, err := NewStore(Options{Path: path, Codec: kv.NewCryptoCodec("HELLO!")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sto.Close()
+
+	j := sto.(kv.Journal)
+	for _, rec := range []string{"one", "two", "three"} {
+		err := j.Append(func([]byte) ([]byte, error) { return []byte(rec), nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the newest record is removed
+	err = sto.(*boltStore).db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(auditBucket)).Delete(itob(3))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if last, err := j.Last(); err != nil || string(last) != "three" {
+		t.Fatalf("expected: three, got: %s (%v)", last, err)
+	}
+}
+
+func TestWriteWithoutCodec(t *testing.T) {
+	path := tempfile()
+	defer os.Remove(path)
+
+	sto, err := NewStore(Options{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sto.Close()
+
+	if err := sto.PutOne("google", "user", "pinco.pallo"); !errors.Is(err, kv.ErrUnsetMasterPassword) {
+		t.Fatalf("expected: %v, got: %v", kv.ErrUnsetMasterPassword, err)
+	}
+
+	err = sto.(kv.Syncer).Apply(kv.Entry{Namespace: "google", Key: "user", Value: "pinco.pallo"})
+	if !errors.Is(err, kv.ErrUnsetMasterPassword) {
+		t.Fatalf("expected: %v, got: %v", kv.ErrUnsetMasterPassword, err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	path := tempfile()
+	defer os.Remove(path)
+
+	sto, err := NewStore(Options{Path: path, Codec: kv.NewCryptoCodec("HELLO!")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, el := range [][]string{{"google", "user"}, {"google", "password"}, {"github", "token"}} {
+		if err := sto.PutOne(el[0], el[1], "abbracadabbra"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sto.DeleteAll("github"); err != nil {
+		t.Fatal(err)
+	}
+	sto.Close()
+
+	// the stats are available on read-only stores too
+	sto, err = NewStore(Options{Path: path, ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sto.Close()
+
+	got, err := sto.(kv.Statter).Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Namespaces != 1 || got.Secrets != 2 || got.SchemaVersion != SchemaVersion() {
+		t.Fatalf("unexpected stats: %+v", got)
+	}
+	if got.Size != fi.Size() {
+		t.Fatalf("expected size: %d, got: %d", fi.Size(), got.Size)
+	}
+	if got.FreePages == 0 || got.FreeBytes == 0 {
+		t.Fatalf("expected free pages after deleting a namespace, got: %+v", got)
+	}
+	if got.Modified.IsZero() || time.Since(got.Modified) > time.Minute {
+		t.Fatalf("unexpected modification time: %v", got.Modified)
+	}
+}
@@ -0,0 +1,178 @@
+package bbolt
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/lucasepe/locker/internal/kv"
+	"go.etcd.io/bbolt"
+)
+
+var _ kv.Syncer = (*boltStore)(nil)
+
+// Entries returns all secrets, including tombstones.
+func (s *boltStore) Entries() (res []kv.Entry, err error) {
+	err = s.db.View(func(tx *bbolt.Tx) error {
+		root := tx.Bucket([]byte(entriesBucket))
+		if root == nil {
+			return nil
+		}
+
+		return root.ForEachBucket(func(ns []byte) error {
+			data := tx.Bucket(ns)
+
+			return root.Bucket(ns).ForEach(func(k, v []byte) error {
+				el := kv.Entry{Namespace: string(ns), Key: string(k)}
+				el.Modified, el.Deleted = decodeMeta(v)
+				if el.Deleted {
+					res = append(res, el)
+					return nil
+				}
+
+				// a secret without value (or namespace) is skipped,
+				// not to be synced as an empty one
+				var val []byte
+				if data != nil {
+					val = data.Get(k)
+				}
+				if val == nil {
+					return nil
+				}
+
+				if s.codec == nil {
+					el.Value = string(val)
+				} else {
+					dst, err := s.codec.Unmarshal(val)
+					if err != nil {
+						return err
+					}
+					el.Value = string(dst)
+				}
+
+				res = append(res, el)
+				return nil
+			})
+		})
+	})
+
+	return res, err
+}
+
+// Apply writes the entries preserving their modification time.
+func (s *boltStore) Apply(entries ...kv.Entry) error {
+	for _, el := range entries {
+		if err := s.checkWrite(el.Namespace); err != nil {
+			return err
+		}
+		if len(el.Key) == 0 {
+			return kv.ErrEmptyKey
+		}
+		if !el.Deleted && s.codec == nil {
+			return kv.ErrUnsetMasterPassword
+		}
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		for _, el := range entries {
+			if el.Deleted {
+				if bkt := tx.Bucket([]byte(el.Namespace)); bkt != nil {
+					if err := bkt.Delete([]byte(el.Key)); err != nil {
+						return err
+					}
+					if k, _ := bkt.Cursor().First(); k == nil {
+						if err := tx.DeleteBucket([]byte(el.Namespace)); err != nil {
+							return err
+						}
+					}
+				}
+				if err := touch(tx, el.Namespace, el.Key, el.Modified, true); err != nil {
+					return err
+				}
+				continue
+			}
+
+			data, err := s.codec.Marshal([]byte(el.Value))
+			if err != nil {
+				return err
+			}
+
+			bkt, err := tx.CreateBucketIfNotExists([]byte(el.Namespace))
+			if err != nil {
+				return err
+			}
+			if err := bkt.Put([]byte(el.Key), data); err != nil {
+				return err
+			}
+			if err := touch(tx, el.Namespace, el.Key, el.Modified, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// LastSync returns the time of the last successful sync.
+func (s *boltStore) LastSync() (last time.Time, err error) {
+	err = s.db.View(func(tx *bbolt.Tx) error {
+		bkt := tx.Bucket([]byte(metaBucket))
+		if bkt == nil {
+			return nil
+		}
+		if data := bkt.Get(keyLastSync); data != nil {
+			last, _ = decodeMeta(data)
+		}
+		return nil
+	})
+
+	return last, err
+}
+
+// SetLastSync records the time of a successful sync.
+func (s *boltStore) SetLastSync(t time.Time) error {
+	if s.readOnly {
+		return kv.ErrNewerSchema
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bkt, err := tx.CreateBucketIfNotExists([]byte(metaBucket))
+		if err != nil {
+			return err
+		}
+		return bkt.Put(keyLastSync, encodeMeta(t, false))
+	})
+}
+
+// touch records the modification time of a secret.
+func touch(tx *bbolt.Tx, namespace, key string, mtime time.Time, deleted bool) error {
+	root, err := tx.CreateBucketIfNotExists([]byte(entriesBucket))
+	if err != nil {
+		return err
+	}
+
+	bkt, err := root.CreateBucketIfNotExists([]byte(namespace))
+	if err != nil {
+		return err
+	}
+
+	return bkt.Put([]byte(key), encodeMeta(mtime, deleted))
+}
+
+// encodeMeta packs the modification time (unix nanoseconds, big endian)
+// followed by one byte flagging tombstones.
+func encodeMeta(mtime time.Time, deleted bool) []byte {
+	buf := make([]byte, 9)
+	binary.BigEndian.PutUint64(buf, uint64(mtime.UnixNano()))
+	if deleted {
+		buf[8] = 1
+	}
+	return buf
+}
+
+func decodeMeta(data []byte) (mtime time.Time, deleted bool) {
+	if len(data) < 9 {
+		return time.Time{}, false
+	}
+
+	nsec := int64(binary.BigEndian.Uint64(data[:8]))
+	return time.Unix(0, nsec), data[8] == 1
+}
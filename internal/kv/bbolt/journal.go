@@ -0,0 +1,118 @@
+package bbolt
+
+import (
+	"encoding/binary"
+
+	"github.com/lucasepe/locker/internal/kv"
+	"go.etcd.io/bbolt"
+)
+
+var _ kv.Journal = (*boltStore)(nil)
+
+// Append adds a record to the audit journal, after the one returned by
+// Last; records are always encrypted, so a codec is required.
+func (s *boltStore) Append(fn func(last []byte) ([]byte, error)) error {
+	if s.readOnly {
+		return kv.ErrNewerSchema
+	}
+
+	if s.codec == nil {
+		return kv.ErrUnsetMasterPassword
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bkt, err := tx.CreateBucketIfNotExists([]byte(auditBucket))
+		if err != nil {
+			return err
+		}
+		meta, err := tx.CreateBucketIfNotExists([]byte(metaBucket))
+		if err != nil {
+			return err
+		}
+
+		var last []byte
+		if val := meta.Get(keyAuditHead); val != nil {
+			last, err = s.codec.Unmarshal(val)
+			if err != nil {
+				return err
+			}
+		}
+
+		rec, err := fn(last)
+		if err != nil {
+			return err
+		}
+
+		data, err := s.codec.Marshal(rec)
+		if err != nil {
+			return err
+		}
+
+		seq, err := bkt.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		if err := bkt.Put(itob(seq), data); err != nil {
+			return err
+		}
+		return meta.Put(keyAuditHead, data)
+	})
+}
+
+// Records calls fn for each decrypted journal record, oldest first.
+func (s *boltStore) Records(fn func(rec []byte) error) error {
+	if s.codec == nil {
+		return kv.ErrUnsetMasterPassword
+	}
+
+	return s.db.View(func(tx *bbolt.Tx) error {
+		bkt := tx.Bucket([]byte(auditBucket))
+		if bkt == nil {
+			return nil
+		}
+
+		c := bkt.Cursor()
+		for _, val := c.First(); val != nil; _, val = c.Next() {
+			rec, err := s.codec.Unmarshal(val)
+			if err != nil {
+				return err
+			}
+			if err := fn(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Last returns the last decrypted record appended to the journal (nil if
+// empty); it is kept apart from the records, in the metadata bucket, so
+// that it is still returned if the newest records are removed.
+func (s *boltStore) Last() (last []byte, err error) {
+	if s.codec == nil {
+		return nil, kv.ErrUnsetMasterPassword
+	}
+
+	err = s.db.View(func(tx *bbolt.Tx) error {
+		bkt := tx.Bucket([]byte(metaBucket))
+		if bkt == nil {
+			return nil
+		}
+
+		if val := bkt.Get(keyAuditHead); val != nil {
+			last, err = s.codec.Unmarshal(val)
+		}
+		return err
+	})
+
+	return last, err
+}
+
+// itob returns an 8-byte big endian representation of v,
+// so that keys sort in insertion order.
+func itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
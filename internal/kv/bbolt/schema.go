@@ -0,0 +1,180 @@
+package bbolt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// reservedPrefix marks buckets used internally by locker;
+	// they are never exposed as namespaces.
+	reservedPrefix = "__"
+	// metaBucket holds the store metadata (i.e. the schema version).
+	metaBucket = reservedPrefix + "locker__"
+	// auditBucket holds the append-only journal records.
+	auditBucket = reservedPrefix + "audit__"
+	// entriesBucket holds, for each namespace, a nested bucket with the
+	// modification time of every secret (tombstones included).
+	entriesBucket = reservedPrefix + "entries__"
+)
+
+var (
+	keySchemaVersion = []byte("schema_version")
+	keyLastSync      = []byte("last_sync")
+	// keyAuditHead is a copy of the last audit journal record,
+	// so that the removal of the newest records is detected.
+	keyAuditHead = []byte("audit_head")
+)
+
+// Migration upgrades the store layout from the previous schema version to Version.
+type Migration struct {
+	// Version is the schema version reached once the migration is applied.
+	Version int
+	// Description is a short human readable summary of the change.
+	Description string
+	// Apply performs the upgrade; it runs inside a read-write transaction
+	// shared with all the other pending migrations.
+	Apply func(tx *bbolt.Tx) error
+}
+
+// migrations is the ordered registry of all schema migrations.
+// Append new entries at the end, incrementing the version by one.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "record the schema version in a reserved metadata bucket",
+		Apply:       func(*bbolt.Tx) error { return nil },
+	},
+	{
+		Version:     2,
+		Description: "add the audit journal bucket",
+		Apply: func(tx *bbolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists([]byte(auditBucket))
+			return err
+		},
+	},
+	{
+		Version:     3,
+		Description: "track the modification time of every secret",
+		Apply: func(tx *bbolt.Tx) error {
+			// the buckets cannot be changed while iterating them
+			var secrets [][2]string
+			err := tx.ForEach(func(bn []byte, bkt *bbolt.Bucket) error {
+				if isReserved(string(bn)) {
+					return nil
+				}
+				return bkt.ForEach(func(k, _ []byte) error {
+					secrets = append(secrets, [2]string{string(bn), string(k)})
+					return nil
+				})
+			})
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			for _, el := range secrets {
+				if err := touch(tx, el[0], el[1], now, false); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     4,
+		Description: "keep a copy of the last audit journal record",
+		Apply: func(tx *bbolt.Tx) error {
+			bkt := tx.Bucket([]byte(auditBucket))
+			if bkt == nil {
+				return nil
+			}
+			_, val := bkt.Cursor().Last()
+			if val == nil {
+				return nil
+			}
+
+			meta, err := tx.CreateBucketIfNotExists([]byte(metaBucket))
+			if err != nil {
+				return err
+			}
+			return meta.Put(keyAuditHead, val)
+		},
+	},
+}
+
+// SchemaVersion returns the store format version written by this build.
+func SchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// migrate brings the store to the current schema version running all the
+// pending migrations in a single transaction; it returns the store version.
+// Stores written by a newer build are left untouched.
+func migrate(db *bbolt.DB) (ver int, err error) {
+	err = db.View(func(tx *bbolt.Tx) error {
+		ver, err = readSchemaVersion(tx)
+		return err
+	})
+	if err != nil || ver >= SchemaVersion() {
+		return ver, err
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, m := range migrations {
+			if m.Version <= ver {
+				continue
+			}
+			if err := m.Apply(tx); err != nil {
+				return fmt.Errorf("schema migration to version %d (%s) failed: %w",
+					m.Version, m.Description, err)
+			}
+		}
+
+		return writeSchemaVersion(tx, SchemaVersion())
+	})
+	if err != nil {
+		return ver, err
+	}
+
+	return SchemaVersion(), nil
+}
+
+// readSchemaVersion returns the recorded schema version;
+// stores created before versioning was introduced are at version 0.
+func readSchemaVersion(tx *bbolt.Tx) (int, error) {
+	bkt := tx.Bucket([]byte(metaBucket))
+	if bkt == nil {
+		return 0, nil
+	}
+
+	data := bkt.Get(keySchemaVersion)
+	if data == nil {
+		return 0, nil
+	}
+
+	ver, err := strconv.Atoi(string(data))
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version: %q", data)
+	}
+
+	return ver, nil
+}
+
+func writeSchemaVersion(tx *bbolt.Tx, ver int) error {
+	bkt, err := tx.CreateBucketIfNotExists([]byte(metaBucket))
+	if err != nil {
+		return err
+	}
+
+	return bkt.Put(keySchemaVersion, []byte(strconv.Itoa(ver)))
+}
+
+// isReserved reports whether the namespace is used internally by locker.
+func isReserved(namespace string) bool {
+	return strings.HasPrefix(namespace, reservedPrefix)
+}
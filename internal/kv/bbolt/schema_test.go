@@ -0,0 +1,237 @@
+package bbolt
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/lucasepe/locker/internal/kv"
+	"go.etcd.io/bbolt"
+)
+
+func TestNewStoreRecordsSchemaVersion(t *testing.T) {
+	path := tempfile()
+	defer os.Remove(path)
+
+	sto, err := NewStore(Options{Path: path, Codec: kv.NewCryptoCodec("HELLO!")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sto.Close()
+
+	if got := storedSchemaVersion(t, path); got != SchemaVersion() {
+		t.Fatalf("expected: %d, got: %d", SchemaVersion(), got)
+	}
+}
+
+func TestNewStoreMigratesLegacyStore(t *testing.T) {
+	path := tempfile()
+	defer os.Remove(path)
+
+	codec := kv.NewCryptoCodec("HELLO!")
+	enc, err := codec.Marshal([]byte("abbracadabbra"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := bbolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		bkt, err := tx.CreateBucket([]byte("google"))
+		if err != nil {
+			return err
+		}
+		return bkt.Put([]byte("password"), enc)
+	})
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sto, err := NewStore(Options{Path: path, Codec: codec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sto.Close()
+
+	got, err := sto.GetOne("google", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "abbracadabbra" {
+		t.Fatalf("expected: abbracadabbra, got: %s", got)
+	}
+
+	all, err := sto.Namespaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all[0] != "google" {
+		t.Fatalf("expected: [google], got: %v", all)
+	}
+
+	entries, err := sto.(kv.Syncer).Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Modified.IsZero() {
+		t.Fatalf("expected one entry with modification time, got: %v", entries)
+	}
+}
+
+func TestNewStoreMigratesAuditHead(t *testing.T) {
+	path := tempfile()
+	defer os.Remove(path)
+
+	codec := kv.NewCryptoCodec("HELLO!")
+	enc, err := codec.Marshal([]byte("last record"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a journal written before the last record was kept apart
+	db, err := bbolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		bkt, err := tx.CreateBucket([]byte(auditBucket))
+		if err != nil {
+			return err
+		}
+		if err := bkt.Put(itob(1), enc); err != nil {
+			return err
+		}
+		return writeSchemaVersion(tx, 3)
+	})
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sto, err := NewStore(Options{Path: path, Codec: codec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sto.Close()
+
+	if last, err := sto.(kv.Journal).Last(); err != nil || string(last) != "last record" {
+		t.Fatalf("expected: last record, got: %s (%v)", last, err)
+	}
+}
+
+func TestNewStoreReadOnlySkipsMigrations(t *testing.T) {
+	path := tempfile()
+	defer os.Remove(path)
+
+	db, err := bbolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucket([]byte("google"))
+		return err
+	})
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sto, err := NewStore(Options{Path: path, ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := sto.Namespaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all[0] != "google" {
+		t.Fatalf("expected: [google], got: %v", all)
+	}
+
+	if err := sto.PutOne("google", "password", "abbracadabbra"); !errors.Is(err, bbolt.ErrDatabaseReadOnly) {
+		t.Fatalf("expected: %v, got: %v", bbolt.ErrDatabaseReadOnly, err)
+	}
+	sto.Close()
+
+	db, err = bbolt.Open(path, 0600, &bbolt.Options{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	db.View(func(tx *bbolt.Tx) error {
+		if tx.Bucket([]byte(metaBucket)) != nil {
+			t.Fatal("read-only store should not be migrated")
+		}
+		return nil
+	})
+}
+
+func TestNewStoreRefusesWritesToNewerSchema(t *testing.T) {
+	path := tempfile()
+	defer os.Remove(path)
+
+	db, err := bbolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		return writeSchemaVersion(tx, SchemaVersion()+1)
+	})
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sto, err := NewStore(Options{Path: path, Codec: kv.NewCryptoCodec("HELLO!")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sto.Close()
+
+	err = sto.PutOne("google", "password", "abbracadabbra")
+	if !errors.Is(err, kv.ErrNewerSchema) {
+		t.Fatalf("expected: %v, got: %v", kv.ErrNewerSchema, err)
+	}
+}
+
+func TestReservedNamespace(t *testing.T) {
+	path := tempfile()
+	defer os.Remove(path)
+
+	sto, err := NewStore(Options{Path: path, Codec: kv.NewCryptoCodec("HELLO!")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sto.Close()
+
+	err = sto.PutOne(metaBucket, string(keySchemaVersion), "0")
+	if !errors.Is(err, kv.ErrReservedNamespace) {
+		t.Fatalf("expected: %v, got: %v", kv.ErrReservedNamespace, err)
+	}
+}
+
+func storedSchemaVersion(t *testing.T, path string) int {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var ver int
+	err = db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket([]byte(metaBucket)).Get(keySchemaVersion)
+		ver, err = strconv.Atoi(string(data))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ver
+}
@@ -0,0 +1,57 @@
+package bbolt
+
+import (
+	"os"
+
+	"github.com/lucasepe/locker/internal/kv"
+	"go.etcd.io/bbolt"
+)
+
+var _ kv.Statter = (*boltStore)(nil)
+
+// Stats returns the statistics of the store.
+func (s *boltStore) Stats() (res kv.Stats, err error) {
+	fi, err := os.Stat(s.db.Path())
+	if err != nil {
+		return res, err
+	}
+	res.Size = fi.Size()
+
+	err = s.db.View(func(tx *bbolt.Tx) error {
+		if res.SchemaVersion, err = readSchemaVersion(tx); err != nil {
+			return err
+		}
+
+		err := tx.ForEach(func(bn []byte, bkt *bbolt.Bucket) error {
+			if isReserved(string(bn)) {
+				return nil
+			}
+			res.Namespaces++
+			res.Secrets += bkt.Stats().KeyN
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		root := tx.Bucket([]byte(entriesBucket))
+		if root == nil {
+			return nil
+		}
+
+		return root.ForEachBucket(func(ns []byte) error {
+			return root.Bucket(ns).ForEach(func(_, v []byte) error {
+				if mtime, _ := decodeMeta(v); mtime.After(res.Modified) {
+					res.Modified = mtime
+				}
+				return nil
+			})
+		})
+	})
+
+	st := s.db.Stats()
+	res.FreePages = st.FreePageN + st.PendingPageN
+	res.FreeBytes = res.FreePages * s.db.Info().PageSize
+
+	return res, err
+}
@@ -2,6 +2,7 @@ package kv
 
 import (
 	"errors"
+	"time"
 )
 
 const (
@@ -11,7 +12,11 @@ const (
 var (
 	ErrEmptyNamespace    = errors.New("namespace cannot be empty")
 	ErrEmptyKey          = errors.New("key cannot be empty")
+	ErrKeyNotFound       = errors.New("key not found")
 	ErrNamespaceNotFound = errors.New("namespace not found")
+	ErrReservedNamespace = errors.New("namespace is reserved")
+	ErrNewerSchema       = errors.New("store was written by a newer version of locker, refusing to modify it")
+	ErrStoreInUse        = errors.New("store is in use by another process (i.e. a locker shell)")
 )
 
 // Store is an abstraction for different key-value store implementations.
@@ -35,3 +40,66 @@ type Store interface {
 	// Close must be called when the work with the key-value store is done.
 	Close() error
 }
+
+// Journal is implemented by stores able to keep an append-only log of records.
+type Journal interface {
+	// Append atomically appends the record returned by fn;
+	// fn receives the last record in the journal (nil if empty).
+	Append(fn func(last []byte) ([]byte, error)) error
+	// Records calls fn for each record, in insertion order.
+	Records(fn func(rec []byte) error) error
+	// Last returns the last record appended to the journal (nil if empty),
+	// even if the newest records have been removed.
+	Last() ([]byte, error)
+}
+
+// Entry is a secret along with its modification metadata.
+type Entry struct {
+	Namespace string
+	Key       string
+	Value     string
+	// Modified is the time of the last change.
+	Modified time.Time
+	// Deleted marks a tombstone: the secret has been removed.
+	Deleted bool
+}
+
+// Syncer is implemented by stores that track the modification time of each
+// secret and keep tombstones for deleted ones, so that they can be merged.
+type Syncer interface {
+	// Entries returns all secrets, including tombstones.
+	Entries() ([]Entry, error)
+	// Apply writes the entries preserving their modification time;
+	// deleted entries remove the secret leaving a tombstone.
+	Apply(entries ...Entry) error
+	// LastSync returns the time of the last successful sync (zero if never synced).
+	LastSync() (time.Time, error)
+	// SetLastSync records the time of a successful sync.
+	SetLastSync(t time.Time) error
+}
+
+// Stats are the statistics of a store.
+type Stats struct {
+	// Namespaces is the number of namespaces.
+	Namespaces int
+	// Secrets is the number of secrets, in all namespaces.
+	Secrets int
+	// Size of the store file, in bytes.
+	Size int64
+	// FreePages is the number of unused pages of the store file
+	// (reused by later writes, or reclaimed compacting it), including
+	// the pending ones, freed by a write still read by open transactions.
+	FreePages int
+	// FreeBytes is the size of the free pages.
+	FreeBytes int
+	// SchemaVersion is the version of the store format.
+	SchemaVersion int
+	// Modified is the time of the last change of a secret (zero if unknown).
+	Modified time.Time
+}
+
+// Statter is implemented by stores able to report their statistics.
+type Statter interface {
+	// Stats returns the statistics of the store.
+	Stats() (Stats, error)
+}
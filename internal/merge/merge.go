@@ -0,0 +1,149 @@
+// Package merge reconciles two copies of a store, key by key.
+//
+// Each secret carries its modification time and deletes leave tombstones,
+// so given the time of the last sync (the common base) an entry changed
+// on one side only is simply propagated to the other side; an entry
+// changed on both sides is a conflict.
+package merge
+
+import (
+	"sort"
+	"time"
+
+	"github.com/lucasepe/locker/internal/kv"
+)
+
+// Strategy tells how to resolve conflicts.
+type Strategy string
+
+const (
+	// Report leaves conflicts unresolved.
+	Report Strategy = ""
+	// KeepLocal resolves conflicts in favor of the local entry.
+	KeepLocal Strategy = "keep-local"
+	// KeepRemote resolves conflicts in favor of the remote entry.
+	KeepRemote Strategy = "keep-remote"
+	// KeepBoth keeps the local entry and saves the remote one
+	// under the same key with the RemoteSuffix.
+	KeepBoth Strategy = "keep-both"
+)
+
+// RemoteSuffix is appended to the key of remote entries saved by KeepBoth.
+const RemoteSuffix = "_remote"
+
+// Conflict is an entry changed on both sides since the last sync.
+type Conflict struct {
+	Local  kv.Entry
+	Remote kv.Entry
+}
+
+// Result holds the changes needed to bring both sides in sync.
+type Result struct {
+	// ToLocal are the entries to apply to the local store.
+	ToLocal []kv.Entry
+	// ToRemote are the entries to apply to the remote store.
+	ToRemote []kv.Entry
+	// Conflicts are the entries left unresolved.
+	Conflicts []Conflict
+}
+
+// Merge compares the local and remote entries given the time of the last
+// sync (zero if the stores were never synced) and returns the changes.
+// Resolved conflicts are stamped with the now time.
+func Merge(local, remote []kv.Entry, base time.Time, strategy Strategy, now time.Time) Result {
+	lm, rm := index(local), index(remote)
+
+	ids := make([]id, 0, len(lm)+len(rm))
+	for k := range lm {
+		ids = append(ids, k)
+	}
+	for k := range rm {
+		if _, ok := lm[k]; !ok {
+			ids = append(ids, k)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if ids[i].namespace != ids[j].namespace {
+			return ids[i].namespace < ids[j].namespace
+		}
+		return ids[i].key < ids[j].key
+	})
+
+	res := Result{}
+	for _, k := range ids {
+		l, lok := lm[k]
+		r, rok := rm[k]
+
+		switch {
+		case lok && !rok:
+			if !l.Deleted {
+				res.ToRemote = append(res.ToRemote, l)
+			}
+		case rok && !lok:
+			if !r.Deleted {
+				res.ToLocal = append(res.ToLocal, r)
+			}
+		case equal(l, r):
+		default:
+			lChanged, rChanged := l.Modified.After(base), r.Modified.After(base)
+			switch {
+			case lChanged && !rChanged:
+				res.ToRemote = append(res.ToRemote, l)
+			case rChanged && !lChanged:
+				res.ToLocal = append(res.ToLocal, r)
+			default:
+				res.resolve(Conflict{Local: l, Remote: r}, strategy, now)
+			}
+		}
+	}
+
+	return res
+}
+
+func (res *Result) resolve(c Conflict, strategy Strategy, now time.Time) {
+	win := c.Local
+	switch strategy {
+	case KeepLocal:
+	case KeepRemote:
+		win = c.Remote
+	case KeepBoth:
+		if c.Local.Deleted {
+			win = c.Remote
+			break
+		}
+		if !c.Remote.Deleted {
+			dup := c.Remote
+			dup.Key = dup.Key + RemoteSuffix
+			dup.Modified = now
+			res.ToLocal = append(res.ToLocal, dup)
+			res.ToRemote = append(res.ToRemote, dup)
+		}
+	default:
+		res.Conflicts = append(res.Conflicts, c)
+		return
+	}
+
+	win.Modified = now
+	res.ToLocal = append(res.ToLocal, win)
+	res.ToRemote = append(res.ToRemote, win)
+}
+
+type id struct {
+	namespace string
+	key       string
+}
+
+func index(entries []kv.Entry) map[id]kv.Entry {
+	res := make(map[id]kv.Entry, len(entries))
+	for _, el := range entries {
+		res[id{el.Namespace, el.Key}] = el
+	}
+	return res
+}
+
+func equal(a, b kv.Entry) bool {
+	if a.Deleted || b.Deleted {
+		return a.Deleted == b.Deleted
+	}
+	return a.Value == b.Value
+}
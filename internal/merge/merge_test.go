@@ -0,0 +1,113 @@
+package merge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/lucasepe/locker/internal/kv"
+)
+
+var (
+	t0   = time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	base = t0.Add(time.Hour)
+	t1   = base.Add(time.Hour)
+	t2   = base.Add(2 * time.Hour)
+	now  = base.Add(24 * time.Hour)
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		local    []kv.Entry
+		remote   []kv.Entry
+		strategy Strategy
+		want     Result
+	}{
+		{
+			name:   "unchanged",
+			local:  []kv.Entry{entry("pwd", "abc", t0)},
+			remote: []kv.Entry{entry("pwd", "abc", t1)},
+			want:   Result{},
+		},
+		{
+			name:   "added locally",
+			local:  []kv.Entry{entry("pwd", "abc", t1)},
+			remote: nil,
+			want:   Result{ToRemote: []kv.Entry{entry("pwd", "abc", t1)}},
+		},
+		{
+			name:   "added remotely",
+			local:  nil,
+			remote: []kv.Entry{entry("pwd", "abc", t1)},
+			want:   Result{ToLocal: []kv.Entry{entry("pwd", "abc", t1)}},
+		},
+		{
+			name:   "changed locally",
+			local:  []kv.Entry{entry("pwd", "xyz", t1)},
+			remote: []kv.Entry{entry("pwd", "abc", t0)},
+			want:   Result{ToRemote: []kv.Entry{entry("pwd", "xyz", t1)}},
+		},
+		{
+			name:   "deleted remotely",
+			local:  []kv.Entry{entry("pwd", "abc", t0)},
+			remote: []kv.Entry{tombstone("pwd", t1)},
+			want:   Result{ToLocal: []kv.Entry{tombstone("pwd", t1)}},
+		},
+		{
+			name:   "conflict reported",
+			local:  []kv.Entry{entry("pwd", "xyz", t1)},
+			remote: []kv.Entry{entry("pwd", "abc", t2)},
+			want: Result{Conflicts: []Conflict{
+				{Local: entry("pwd", "xyz", t1), Remote: entry("pwd", "abc", t2)},
+			}},
+		},
+		{
+			name:     "conflict keep remote",
+			local:    []kv.Entry{entry("pwd", "xyz", t1)},
+			remote:   []kv.Entry{entry("pwd", "abc", t2)},
+			strategy: KeepRemote,
+			want: Result{
+				ToLocal:  []kv.Entry{entry("pwd", "abc", now)},
+				ToRemote: []kv.Entry{entry("pwd", "abc", now)},
+			},
+		},
+		{
+			name:     "conflict keep both",
+			local:    []kv.Entry{entry("pwd", "xyz", t1)},
+			remote:   []kv.Entry{entry("pwd", "abc", t2)},
+			strategy: KeepBoth,
+			want: Result{
+				ToLocal:  []kv.Entry{entry("pwd_remote", "abc", now), entry("pwd", "xyz", now)},
+				ToRemote: []kv.Entry{entry("pwd_remote", "abc", now), entry("pwd", "xyz", now)},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Merge(tc.local, tc.remote, base, tc.strategy, now)
+			if !cmp.Equal(tc.want, got) {
+				t.Fatal(cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestMergeNeverSynced(t *testing.T) {
+	local := []kv.Entry{entry("pwd", "xyz", t0)}
+	remote := []kv.Entry{entry("pwd", "abc", t1)}
+
+	got := Merge(local, remote, time.Time{}, Report, now)
+	if len(got.Conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got: %v", got)
+	}
+}
+
+func entry(key, val string, mtime time.Time) kv.Entry {
+	return kv.Entry{Namespace: "google", Key: key, Value: val, Modified: mtime}
+}
+
+func tombstone(key string, mtime time.Time) kv.Entry {
+	return kv.Entry{Namespace: "google", Key: key, Modified: mtime, Deleted: true}
+}
@@ -0,0 +1,201 @@
+// Package passgen generates random passwords and diceware-style passphrases
+// using a cryptographically secure random source.
+package passgen
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+const (
+	lowerChars = "abcdefghijklmnopqrstuvwxyz"
+	upperChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars = "0123456789"
+	// DefaultSymbols is the set of symbols used by the DefaultPolicy.
+	DefaultSymbols = "!#$%&*+-.:;=?@^_~"
+	// ambiguousChars are easily confused when read or typed.
+	ambiguousChars = "Il1|O0o`'\"()[]{}/\\,;:."
+)
+
+var (
+	ErrInvalidLength = errors.New("password length must be greater than the required characters")
+	ErrNoCharacters  = errors.New("at least one character class must be enabled")
+	ErrEmptyClass    = errors.New("no symbols left once the ambiguous characters are excluded")
+	ErrInvalidWords  = errors.New("passphrase must have at least one word")
+)
+
+//go:embed wordlist.txt
+var wordlistData string
+
+var wordlist = strings.Fields(wordlistData)
+
+// Policy defines how passwords are generated.
+type Policy struct {
+	// Length is the number of characters.
+	Length int
+	// Lower, Upper and Digits enable the character classes.
+	Lower  bool
+	Upper  bool
+	Digits bool
+	// Symbols is the set of allowed symbols (empty disables them).
+	Symbols string
+	// MinSymbols is the minimum number of symbols.
+	MinSymbols int
+	// ExcludeAmbiguous removes characters easily confused (i.e. 'l', '1', 'O', '0').
+	ExcludeAmbiguous bool
+}
+
+// DefaultPolicy generates 20 characters passwords using all the character
+// classes, with at least one symbol.
+var DefaultPolicy = Policy{
+	Length:     20,
+	Lower:      true,
+	Upper:      true,
+	Digits:     true,
+	Symbols:    DefaultSymbols,
+	MinSymbols: 1,
+}
+
+// Password returns a random password honoring the policy:
+// every enabled letters and digits class is used at least once,
+// the symbols (any Unicode character) at least MinSymbols times;
+// Length is in characters, not bytes.
+func Password(p Policy) (string, error) {
+	type class struct {
+		chars []rune
+		min   int
+	}
+
+	var classes []class
+	for _, el := range []struct {
+		enabled bool
+		chars   string
+		min     int
+	}{
+		{p.Lower, lowerChars, 1},
+		{p.Upper, upperChars, 1},
+		{p.Digits, digitChars, 1},
+		{len(p.Symbols) > 0, p.Symbols, p.MinSymbols},
+	} {
+		if !el.enabled {
+			continue
+		}
+
+		chars := el.chars
+		if p.ExcludeAmbiguous {
+			chars = strip(chars, ambiguousChars)
+		}
+		// only the symbols can be all ambiguous
+		if len(chars) == 0 {
+			return "", ErrEmptyClass
+		}
+		classes = append(classes, class{chars: []rune(chars), min: el.min})
+	}
+
+	if len(classes) == 0 {
+		return "", ErrNoCharacters
+	}
+
+	required := 0
+	var all []rune
+	for _, c := range classes {
+		required = required + c.min
+		all = append(all, c.chars...)
+	}
+	if p.Length < required {
+		return "", ErrInvalidLength
+	}
+
+	res := make([]rune, 0, p.Length)
+	for _, c := range classes {
+		for i := 0; i < c.min; i++ {
+			ch, err := pick(c.chars)
+			if err != nil {
+				return "", err
+			}
+			res = append(res, ch)
+		}
+	}
+
+	for len(res) < p.Length {
+		ch, err := pick(all)
+		if err != nil {
+			return "", err
+		}
+		res = append(res, ch)
+	}
+
+	// shuffle so that the required characters are not at the beginning
+	for i := len(res) - 1; i > 0; i-- {
+		j, err := randInt(i + 1)
+		if err != nil {
+			return "", err
+		}
+		res[i], res[j] = res[j], res[i]
+	}
+
+	return string(res), nil
+}
+
+// Passphrase returns the specified number of random words
+// from the embedded wordlist joined by sep.
+func Passphrase(words int, sep string) (string, error) {
+	if words < 1 {
+		return "", ErrInvalidWords
+	}
+
+	res := make([]string, words)
+	for i := range res {
+		j, err := randInt(len(wordlist))
+		if err != nil {
+			return "", err
+		}
+		res[i] = wordlist[j]
+	}
+
+	return strings.Join(res, sep), nil
+}
+
+// IsWord reports whether s is in the embedded wordlist.
+func IsWord(s string) bool {
+	s = strings.ToLower(s)
+	for _, w := range wordlist {
+		if w == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Words returns a copy of the embedded wordlist.
+func Words() []string {
+	return append([]string(nil), wordlist...)
+}
+
+func pick(chars []rune) (rune, error) {
+	i, err := randInt(len(chars))
+	if err != nil {
+		return 0, err
+	}
+	return chars[i], nil
+}
+
+func randInt(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(v.Int64()), nil
+}
+
+func strip(s, chars string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(chars, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,120 @@
+package passgen
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestPassword(t *testing.T) {
+	p := DefaultPolicy
+	p.MinSymbols = 3
+	p.ExcludeAmbiguous = true
+
+	for i := 0; i < 100; i++ {
+		got, err := Password(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got) != p.Length {
+			t.Fatalf("expected length: %d, got: %d (%s)", p.Length, len(got), got)
+		}
+
+		for _, set := range []string{lowerChars, upperChars, digitChars} {
+			if !strings.ContainsAny(got, set) {
+				t.Fatalf("expected a char of %q in: %s", set, got)
+			}
+		}
+
+		symbols := 0
+		for _, r := range got {
+			if strings.ContainsRune(p.Symbols, r) {
+				symbols = symbols + 1
+			}
+		}
+		if symbols < p.MinSymbols {
+			t.Fatalf("expected at least %d symbols in: %s", p.MinSymbols, got)
+		}
+
+		if strings.ContainsAny(got, ambiguousChars) {
+			t.Fatalf("unexpected ambiguous char in: %s", got)
+		}
+	}
+}
+
+func TestPasswordInvalidPolicy(t *testing.T) {
+	if _, err := Password(Policy{Length: 10}); !errors.Is(err, ErrNoCharacters) {
+		t.Fatalf("expected: %v, got: %v", ErrNoCharacters, err)
+	}
+
+	p := DefaultPolicy
+	p.Length = 3
+	if _, err := Password(p); !errors.Is(err, ErrInvalidLength) {
+		t.Fatalf("expected: %v, got: %v", ErrInvalidLength, err)
+	}
+}
+
+func TestPasswordNoMinSymbols(t *testing.T) {
+	p := DefaultPolicy
+	p.MinSymbols = 0
+	// only room for a lowercase, an uppercase and a digit
+	p.Length = 3
+
+	got, err := Password(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != p.Length {
+		t.Fatalf("expected length: %d, got: %d (%s)", p.Length, len(got), got)
+	}
+	if strings.ContainsAny(got, p.Symbols) {
+		t.Fatalf("unexpected symbol in: %s", got)
+	}
+}
+
+func TestPasswordUnicodeSymbols(t *testing.T) {
+	p := DefaultPolicy
+	p.Symbols = "€£§"
+	p.MinSymbols = 5
+
+	got, err := Password(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !utf8.ValidString(got) {
+		t.Fatalf("invalid UTF-8: %q", got)
+	}
+	if n := utf8.RuneCountInString(got); n != p.Length {
+		t.Fatalf("expected length: %d, got: %d (%s)", p.Length, n, got)
+	}
+	if n := len([]rune(got)) - len([]rune(strip(got, p.Symbols))); n < p.MinSymbols {
+		t.Fatalf("expected at least %d symbols, got: %d (%s)", p.MinSymbols, n, got)
+	}
+
+	// no symbols left
+	p.Symbols = "()[]"
+	p.ExcludeAmbiguous = true
+	if _, err := Password(p); !errors.Is(err, ErrEmptyClass) {
+		t.Fatalf("expected: %v, got: %v", ErrEmptyClass, err)
+	}
+}
+
+func TestPassphrase(t *testing.T) {
+	got, err := Passphrase(6, "-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	words := strings.Split(got, "-")
+	if len(words) != 6 {
+		t.Fatalf("expected 6 words, got: %s", got)
+	}
+
+	for _, w := range words {
+		if !IsWord(w) {
+			t.Fatalf("unexpected word: %s", w)
+		}
+	}
+}
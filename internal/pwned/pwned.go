@@ -0,0 +1,160 @@
+// Package pwned looks up passwords in a local copy of the Have I Been Pwned
+// SHA-1 password hashes, without sending anything over the network.
+//
+// Two layouts are supported:
+//   - a single file with one 'HASH:COUNT' line per hash, sorted by hash
+//     (i.e. pwned-passwords-sha1-ordered-by-hash.txt);
+//   - a directory of range files, named after the first five hex digits
+//     of the hashes (i.e. 21BD1.txt) and holding 'SUFFIX:COUNT' lines.
+package pwned
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DB is a local Have I Been Pwned database.
+type DB struct {
+	path string
+	// fp is the sorted hashes file (nil for range directories).
+	fp   *os.File
+	size int64
+}
+
+// Open opens the sorted hashes file or the range files directory at path.
+func Open(path string) (*DB, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if fi.IsDir() {
+		return &DB{path: path}, nil
+	}
+
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DB{path: path, fp: fp, size: fi.Size()}, nil
+}
+
+// Close releases the database.
+func (db *DB) Close() error {
+	if db.fp == nil {
+		return nil
+	}
+	return db.fp.Close()
+}
+
+// Lookup returns how many times the password has been seen in breaches
+// (zero if never).
+func (db *DB) Lookup(password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	if db.fp == nil {
+		return db.lookupRange(hash)
+	}
+	return db.lookupSorted(hash)
+}
+
+// lookupRange scans the range file of the hash prefix.
+func (db *DB) lookupRange(hash string) (int, error) {
+	fp, err := os.Open(filepath.Join(db.path, hash[:5]+".txt"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer fp.Close()
+
+	sc := bufio.NewScanner(fp)
+	for sc.Scan() {
+		suffix, count := parseLine(sc.Text())
+		if suffix == hash[5:] {
+			return count, nil
+		}
+	}
+
+	return 0, sc.Err()
+}
+
+// lookupSorted binary searches the hash in the sorted file.
+//
+// The invariant is that the line holding the hash, if any,
+// starts at an offset in [lo, hi).
+func (db *DB) lookupSorted(hash string) (int, error) {
+	lo, hi := int64(0), db.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		line, start, next, err := db.lineFrom(mid)
+		if err != nil {
+			return 0, err
+		}
+
+		if start >= hi || len(line) == 0 {
+			hi = mid
+			continue
+		}
+
+		key, count := parseLine(line)
+		switch {
+		case key == hash:
+			return count, nil
+		case key < hash:
+			lo = next
+		default:
+			hi = mid
+		}
+	}
+
+	return 0, nil
+}
+
+// lineFrom returns the first line starting at or after off,
+// its start offset and the offset of the following line.
+func (db *DB) lineFrom(off int64) (line string, start, next int64, err error) {
+	start = off
+	if off > 0 {
+		// skip the rest of the line holding the previous byte
+		start = off - 1
+	}
+
+	rdr := bufio.NewReader(io.NewSectionReader(db.fp, start, db.size-start))
+	if off > 0 {
+		skip, err := rdr.ReadString('\n')
+		if err == io.EOF {
+			return "", db.size, db.size, nil
+		}
+		if err != nil {
+			return "", 0, 0, err
+		}
+		start = start + int64(len(skip))
+	}
+
+	line, err = rdr.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", 0, 0, err
+	}
+
+	return strings.TrimRight(line, "\r\n"), start, start + int64(len(line)), nil
+}
+
+func parseLine(line string) (hash string, count int) {
+	hash, cnt, _ := strings.Cut(strings.TrimSpace(line), ":")
+	count, err := strconv.Atoi(cnt)
+	if err != nil {
+		count = 1
+	}
+	return strings.ToUpper(hash), count
+}
@@ -0,0 +1,87 @@
+package pwned
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var breached = map[string]int{
+	"password":      9545824,
+	"123456":        37359195,
+	"abbracadabbra": 3,
+	"magick":        12,
+}
+
+func TestLookupSorted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+
+	var lines []string
+	for pwd, count := range breached {
+		lines = append(lines, fmt.Sprintf("%s:%d", sha1Hex(pwd), count))
+	}
+	// some filler lines so the binary search has work to do
+	for i := 0; i < 500; i++ {
+		lines = append(lines, fmt.Sprintf("%s:%d", sha1Hex(fmt.Sprint("filler", i)), i+1))
+	}
+	sort.Strings(lines)
+
+	err := os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testLookup(t, path)
+}
+
+func TestLookupRange(t *testing.T) {
+	dir := t.TempDir()
+
+	for pwd, count := range breached {
+		hash := sha1Hex(pwd)
+		fp, err := os.OpenFile(filepath.Join(dir, hash[:5]+".txt"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(fp, "%s:%d\r\n", hash[5:], count)
+		fp.Close()
+	}
+
+	testLookup(t, dir)
+}
+
+func testLookup(t *testing.T, path string) {
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for pwd, want := range breached {
+		got, err := db.Lookup(pwd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("%s: expected: %d, got: %d", pwd, want, got)
+		}
+	}
+
+	got, err := db.Lookup("x7#Kq9!mZ2@wLp4$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 0 {
+		t.Fatalf("expected: 0, got: %d", got)
+	}
+}
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
@@ -14,6 +14,10 @@ import (
 // invalid inputs.
 var ErrDecryptFailed = errors.New("decrypt failed")
 
+// Scheme is the encryption scheme: AES-256-CFB, with the key
+// derived from the master secret hashing it with SHA-256.
+const Scheme = "aes-256-cfb/sha-256"
+
 // Encrypt data. Uses AES-256-CFB encrypter.
 func Encrypt(key []byte, data []byte) ([]byte, error) {
 	keyb := sha256.Sum256(key)
@@ -0,0 +1,117 @@
+// Package strength estimates how easy a password is to guess.
+package strength
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/lucasepe/locker/internal/passgen"
+)
+
+// commonPasswords are among the most used (and guessed) passwords.
+var commonPasswords = map[string]bool{
+	"123456": true, "123456789": true, "12345678": true, "12345": true,
+	"1234567": true, "1234567890": true, "111111": true, "000000": true,
+	"123123": true, "654321": true, "666666": true, "121212": true,
+	"password": true, "password1": true, "passw0rd": true, "p@ssw0rd": true,
+	"qwerty": true, "qwerty123": true, "qwertyuiop": true, "asdfgh": true,
+	"asdfghjkl": true, "zxcvbnm": true, "1q2w3e4r": true, "1qaz2wsx": true,
+	"abc123": true, "iloveyou": true, "admin": true, "administrator": true,
+	"welcome": true, "letmein": true, "monkey": true, "dragon": true,
+	"football": true, "baseball": true, "superman": true, "batman": true,
+	"sunshine": true, "princess": true, "master": true, "shadow": true,
+	"michael": true, "jennifer": true, "trustno1": true, "starwars": true,
+	"hello": true, "freedom": true, "whatever": true, "secret": true,
+	"changeme": true, "default": true, "root": true, "toor": true,
+	"test": true, "guest": true, "login": true, "access": true,
+}
+
+// leet undoes the most common character substitutions.
+var leet = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s", "!", "i",
+)
+
+// Entropy estimates the bits of entropy of s, assuming that each character
+// is picked at random from the union of the character classes it uses;
+// characters repeating or following the previous one (i.e. 'aa', 'ab', '12')
+// do not add entropy.
+func Entropy(s string) float64 {
+	var lower, upper, digit, symbol, other bool
+	effective := 0
+	var prev rune = -1
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII && unicode.IsPrint(r):
+			symbol = true
+		default:
+			other = true
+		}
+
+		if prev < 0 || (r != prev && r != prev+1 && r != prev-1) {
+			effective = effective + 1
+		}
+		prev = r
+	}
+
+	pool := 0
+	for _, c := range []struct {
+		ok   bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if c.ok {
+			pool = pool + c.size
+		}
+	}
+
+	if pool == 0 {
+		return 0
+	}
+
+	return float64(effective) * math.Log2(float64(pool))
+}
+
+// Common reports whether s is one of the most used passwords.
+func Common(s string) bool {
+	return commonPasswords[strings.ToLower(s)]
+}
+
+// Dictionary reports whether s, once the common character substitutions
+// are undone and the leading and trailing digits and symbols are removed,
+// is a common password, a dictionary word or a pair of them.
+func Dictionary(s string) bool {
+	base := strings.ToLower(s)
+	base = strings.TrimFunc(base, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '@' && r != '$'
+	})
+	base = leet.Replace(base)
+	base = strings.TrimFunc(base, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	if len(base) == 0 {
+		return false
+	}
+
+	if Common(base) || passgen.IsWord(base) {
+		return true
+	}
+
+	for i := 3; i <= len(base)-3; i++ {
+		if isWord(base[:i]) && isWord(base[i:]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isWord(s string) bool {
+	return Common(s) || passgen.IsWord(s)
+}
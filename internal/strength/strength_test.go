@@ -0,0 +1,42 @@
+package strength
+
+import "testing"
+
+func TestEntropy(t *testing.T) {
+	tests := []struct {
+		pwd string
+		min float64
+		max float64
+	}{
+		{"", 0, 0},
+		{"aaaaaaaa", 4, 5},
+		{"abcdefgh", 4, 5},
+		{"Tr0ub4dor&3", 60, 75},
+		{"x7#Kq9!mZ2@wLp4$", 100, 110},
+	}
+
+	for _, tc := range tests {
+		got := Entropy(tc.pwd)
+		if got < tc.min || got > tc.max {
+			t.Fatalf("%s: expected entropy in [%.0f, %.0f], got: %.2f", tc.pwd, tc.min, tc.max, got)
+		}
+	}
+}
+
+func TestDictionary(t *testing.T) {
+	tests := map[string]bool{
+		"password":         true,
+		"P@ssw0rd!":        true,
+		"dragon2023":       true,
+		"Sunshine!!":       true,
+		"blueocean":        true,
+		"x7#Kq9!mZ2@wLp4$": false,
+		"2023":             false,
+	}
+
+	for pwd, want := range tests {
+		if got := Dictionary(pwd); got != want {
+			t.Fatalf("%s: expected: %v, got: %v", pwd, want, got)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -14,6 +15,10 @@ var (
 
 func main() {
 	err := cmd.Run(Version, Build)
+	var ee *cmd.ExitError
+	if errors.As(err, &ee) {
+		os.Exit(ee.Code)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
 		os.Exit(1)